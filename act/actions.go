@@ -1,13 +1,20 @@
 package act
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"log"
+	"math/big"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"dcposch.eth/cli/eth"
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 )
 
 // Represents a single user action.
@@ -23,12 +30,22 @@ type ActSetUrl struct {
 
 func (a *ActSetUrl) Run() {
 	url := a.Url
-	tab := &state.Tab
+	tab := state.ActiveTab()
+
+	if tab.EnteredAddr != "" {
+		tab.Back = append(tab.Back, tab.historyEntry())
+		tab.Forward = nil
+	}
 
 	tab.EnteredAddr = url
 	tab.ErrorText = ""
 	tab.ContractAddr = nil
+	tab.Contract = eth.NamedAddr{}
 	tab.AppErrorText = ""
+	tab.NoticeText = ""
+	tab.AppState = nil
+	tab.Inputs = nil
+	tab.Vdom = nil
 
 	// Navigation is always to a UI contract.
 	// User either enters an address directly, or an ENS name.
@@ -39,15 +56,63 @@ func (a *ActSetUrl) Run() {
 			tab.ErrorText = err.Error()
 		} else {
 			tab.ContractAddr = &result
+			tab.Contract = eth.NamedAddr{Addr: result, Name: url}
 		}
 	} else if strings.HasPrefix(url, "0x") {
 		addr := common.HexToAddress(url)
 		tab.ContractAddr = &addr
+		tab.Contract = client.ReverseResolve(addr)
 	} else {
 		tab.EnteredAddr = ""
 	}
 
-	reloadTab()
+	reloadTab(tab)
+}
+
+// Go back to the previous page in this tab's history.
+type ActGoBack struct {
+}
+
+func (a *ActGoBack) Run() {
+	tab := state.ActiveTab()
+	n := len(tab.Back)
+	if n == 0 {
+		return
+	}
+	entry := tab.Back[n-1]
+	tab.Back = tab.Back[:n-1]
+	tab.Forward = append(tab.Forward, tab.historyEntry())
+	restoreHistory(tab, entry)
+}
+
+// Undo a previous ActGoBack.
+type ActGoForward struct {
+}
+
+func (a *ActGoForward) Run() {
+	tab := state.ActiveTab()
+	n := len(tab.Forward)
+	if n == 0 {
+		return
+	}
+	entry := tab.Forward[n-1]
+	tab.Forward = tab.Forward[:n-1]
+	tab.Back = append(tab.Back, tab.historyEntry())
+	restoreHistory(tab, entry)
+}
+
+func restoreHistory(tab *TabState, entry HistoryEntry) {
+	tab.EnteredAddr = entry.EnteredAddr
+	tab.ContractAddr = entry.ContractAddr
+	tab.Contract = entry.Contract
+	tab.AppState = entry.AppState
+	tab.Inputs = entry.Inputs
+	tab.ErrorText = ""
+	tab.AppErrorText = ""
+	tab.Vdom = nil
+
+	render()
+	reloadTab(tab)
 }
 
 // Update a form input.
@@ -57,7 +122,12 @@ type ActSetInput struct {
 }
 
 func (a *ActSetInput) Run() {
-	state.Tab.Inputs[a.Key] = a.Val
+	tab := state.ActiveTab()
+	// The form may have changed since the input was edited
+	if int(a.Key) >= len(tab.Inputs) {
+		return
+	}
+	tab.Inputs[a.Key] = a.Val
 }
 
 // Submit a form.
@@ -66,110 +136,689 @@ type ActSubmit struct {
 }
 
 func (a *ActSubmit) Run() {
-	inputs := state.Tab.Inputs
-	appState := []byte{}
-	contractAddr := *state.Tab.ContractAddr
+	tab := state.ActiveTab()
+	if tab.ContractAddr == nil {
+		// Queued before the tab loaded, or it failed to
+		return
+	}
+	inputs := tab.Inputs
+	appState := tab.AppState
+	contractAddr := *tab.ContractAddr
 	action := eth.ButtonAction{ButtonKey: a.ButtonKey, Inputs: inputs}
-	callMsg, err := client.FrontendSubmit(state.Chain.Account.Addr, contractAddr, appState, action)
+	calls, newAppState, err := client.FrontendSubmit(state.Chain.Account.Addr, contractAddr, appState, action)
 	log.Printf("act Submit %d err %v", a.ButtonKey, err)
 
-	if err == nil {
-		state.Tab.AppErrorText = ""
-		state.Tab.ProposedTx = callMsg
-	} else {
-		state.Tab.AppErrorText = err.Error()
+	if err != nil {
+		tab.AppErrorText = err.Error()
+		render()
+		return
 	}
 
-	render()
+	tab.AppErrorText = ""
+	tab.ProposedTxs = calls
+	tab.PreparedTx = nil
+	tab.TxStep = 1
+	tab.TxCount = len(calls)
+	if len(calls) > 0 && (state.Chain.CanSign() || state.Chain.IsWatchOnly()) {
+		prepareNextTx(tab, 0, true)
+	} else if len(calls) > 0 && state.Chain.Locked {
+		state.Chain.UnlockPrompt = true
+	}
+	if !bytes.Equal(newAppState, appState) {
+		// The app moved to a new state, eg the next step of a wizard.
+		tab.AppState = newAppState
+		tab.Inputs = nil
+		reloadTab(tab)
+	} else {
+		render()
+	}
 }
 
+// Sign and send the next proposed transaction.
 type ActExecTx struct {
 }
 
 func (a *ActExecTx) Run() {
-	tx, err := client.Execute(state.Tab.ProposedTx, state.Chain.PrivateKey)
-	state.Tab.ProposedTx = nil
+	tab := state.ActiveTab()
+	if tab.PreparedTx == nil || tab.PendingTx != nil {
+		return
+	}
+	if tab.PreparedTx.Gas() == 0 {
+		// Follows an exported step, so the user must enter a gas limit
+		tab.ShowFees = true
+		render()
+		return
+	}
+	tx, err := client.Execute(tab.PreparedTx, tab.PreparedChainID, state.Chain.Signer)
+	tab.ProposedTxs = tab.ProposedTxs[1:]
+	tab.PreparedTx = nil
 	if err == nil {
-		state.Tab.PendingTx = tx
+		setPending(tab, tx, state.Chain.Account.Addr)
+		logTxSent(tab, tx, state.Chain.Account.Addr)
+	} else {
+		clearPending(tab)
+		tab.ErrorText = err.Error()
+		abortTxs(tab)
+	}
+
+	render()
+}
+
+// Write the next proposed transaction, unsigned, to a file for offline signing.
+type ActExportTx struct {
+}
+
+func (a *ActExportTx) Run() {
+	tab := state.ActiveTab()
+	tx := tab.PreparedTx
+	if tx == nil || tab.PendingTx != nil {
+		return
+	}
+	if tx.Gas() == 0 {
+		tab.ShowFees = true
+		render()
+		return
+	}
+
+	path, err := exportTx(tx, tab.PreparedChainID)
+	if err != nil {
+		tab.AppErrorText = err.Error()
+		abortTxs(tab)
+		render()
+		return
+	}
+	tab.NoticeText = fmt.Sprintf("Exported unsigned tx %d of %d to %s", tab.TxStep, tab.TxCount, path)
+
+	// Batch continues with the next nonce, without waiting for a receipt.
+	// The next call may depend on this one, eg a swap after an approve, so
+	// its gas can't be estimated yet. The user enters a limit instead.
+	tab.ProposedTxs = tab.ProposedTxs[1:]
+	tab.PreparedTx = nil
+	if len(tab.ProposedTxs) > 0 {
+		tab.TxStep++
+		prepareNextTx(tab, tx.Nonce()+1, false)
 	} else {
-		state.Tab.PendingTx = nil
-		state.Tab.ErrorText = err.Error()
+		abortTxs(tab)
 	}
 
 	render()
 }
 
+func exportTx(tx *types.Transaction, chainID *big.Int) (string, error) {
+	js, err := eth.ExportUnsignedTx(tx, chainID, state.Chain.Account.Addr)
+	if err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("unsigned-tx-%s-%s-%d.json", chainID, state.Chain.Account.Addr, tx.Nonce())
+	path := filepath.Join(keyOpts.ExportDir, name)
+	log.Printf("act exporting unsigned tx to %s", path)
+	return path, os.WriteFile(path, js, 0644)
+}
+
+// Show or hide the raw transaction import page.
+type ActShowImport struct {
+	Show bool
+}
+
+func (a *ActShowImport) Run() {
+	state.Import = ImportState{Show: a.Show}
+
+	render()
+}
+
+// Decode a pre-signed transaction for review. Raw is hex RLP or a file path.
+type ActImportRaw struct {
+	Raw string
+}
+
+func (a *ActImportRaw) Run() {
+	imp := &state.Import
+	imp.Tx = nil
+	imp.ErrorText = ""
+
+	raw := []byte(strings.TrimSpace(a.Raw))
+	if _, err := os.Stat(string(raw)); err == nil {
+		raw, err = os.ReadFile(string(raw))
+		if err != nil {
+			imp.ErrorText = err.Error()
+			render()
+			return
+		}
+	}
+
+	tx, from, err := eth.DecodeSignedTx(raw)
+	if err != nil {
+		imp.ErrorText = err.Error()
+	} else {
+		imp.Tx = tx
+		imp.From = from
+		chainID := state.Chain.Conn.ChainID
+		if tx.Protected() && tx.ChainId().Int64() != chainID {
+			imp.ErrorText = fmt.Sprintf("transaction is for chain %s, connected to %d", tx.ChainId(), chainID)
+		}
+	}
+
+	render()
+}
+
+// Broadcast the imported transaction and track it in the active tab.
+type ActBroadcastRaw struct {
+}
+
+func (a *ActBroadcastRaw) Run() {
+	imp := &state.Import
+	tab := state.ActiveTab()
+	if imp.Tx == nil || imp.ErrorText != "" || tab.PendingTx != nil {
+		return
+	}
+
+	log.Printf("act broadcasting raw tx %s from %s", imp.Tx.Hash(), imp.From)
+	err := client.Ec.SendTransaction(context.Background(), imp.Tx)
+	if err != nil {
+		imp.ErrorText = err.Error()
+		render()
+		return
+	}
+
+	abortTxs(tab)
+	setPending(tab, imp.Tx, imp.From)
+	logTxSent(tab, imp.Tx, imp.From)
+	state.Import = ImportState{}
+
+	render()
+}
+
+// Reject the proposed transactions, or stop tracking a pending one. The
+// pending transaction stays in the mempool; see ActReplaceTx to cancel it.
 type ActCancelTx struct {
 }
 
 func (a *ActCancelTx) Run() {
-	state.Tab.ProposedTx = nil
-	state.Tab.PendingTx = nil
+	tab := state.ActiveTab()
+	clearPending(tab)
+	abortTxs(tab)
 
 	render()
 }
 
-func reloadTxState() {
-	tx := state.Tab.PendingTx
-	if tx == nil {
+// Replace the pending transaction at the same nonce with higher fees. Speeds
+// it up, or cancels it by sending a zero-value transfer to self instead.
+type ActReplaceTx struct {
+	Cancel bool
+}
+
+func (a *ActReplaceTx) Run() {
+	tab := state.ActiveTab()
+	if !state.CanReplace(tab) {
 		return
 	}
-	ctx := context.Background()
 
-	receipt, err := client.Ec.TransactionReceipt(ctx, tx.Hash())
+	old := tab.PendingTx
+	var tx *types.Transaction
+	var err error
+	if a.Cancel {
+		tx, err = client.CancelTx(old, state.Chain.Signer)
+	} else {
+		tx, err = client.SpeedUpTx(old, state.Chain.Signer)
+	}
 	if err != nil {
-		log.Printf("act reloadTxState error %v", err)
+		tab.AppErrorText = err.Error()
+		render()
 		return
 	}
-	if receipt == nil {
+	log.Printf("act replaced tx %s with %s, cancel %v", old.Hash(), tx.Hash(), a.Cancel)
+
+	tab.AppErrorText = ""
+	tab.ReplacedTxs = append(tab.ReplacedTxs, old)
+	tab.PendingTx = tx
+	logTxReplaced(old, tx)
+
+	render()
+}
+
+func setPending(tab *TabState, tx *types.Transaction, from common.Address) {
+	tab.PendingTx = tx
+	tab.PendingFrom = from
+	tab.ReplacedTxs = nil
+}
+
+func clearPending(tab *TabState) {
+	setPending(tab, nil, common.Address{})
+}
+
+// Fills in nonce, gas and fees for the next transaction in the queue.
+func prepareNextTx(tab *TabState, minNonce uint64, estimate bool) {
+	err := func() (err error) {
+		tab.FeeOptions, err = client.EstimateFees(context.Background())
+		if err != nil {
+			return err
+		}
+		tab.PreparedChainID = big.NewInt(state.Chain.Conn.ChainID)
+		tab.PreparedTx, err = client.PrepareTx(&tab.ProposedTxs[0], tab.PreparedChainID, minNonce, feePreset(tab), estimate)
+		if err != nil {
+			return err
+		}
+		if to := tab.PreparedTx.To(); to != nil {
+			tab.PreparedTo = client.ReverseResolve(*to)
+		}
+		return nil
+	}()
+	if err != nil {
+		tab.AppErrorText = fmt.Sprintf("transaction %d of %d: %s", tab.TxStep, tab.TxCount, err)
+		abortTxs(tab)
+	}
+}
+
+// Keeps the user's fee preset across a batch. Custom fees reset to normal.
+func feePreset(tab *TabState) eth.Fee {
+	switch tab.FeeLabel {
+	case "slow":
+		return tab.FeeOptions.Slow
+	case "fast":
+		return tab.FeeOptions.Fast
+	default:
+		tab.FeeLabel = "normal"
+		return tab.FeeOptions.Normal
+	}
+}
+
+// Show or hide the fee editor for the proposed transaction.
+type ActShowFees struct {
+	Show bool
+}
+
+func (a *ActShowFees) Run() {
+	tab := state.ActiveTab()
+	tab.ShowFees = a.Show && tab.PreparedTx != nil
+
+	render()
+}
+
+// Change the fees of the proposed transaction, to a preset or custom values.
+type ActSetFee struct {
+	// "slow", "normal", "fast" or "custom"
+	Label string
+	Fee   eth.Fee
+	// Gas limit, or 0 to keep the current one
+	Gas uint64
+}
+
+func (a *ActSetFee) Run() {
+	tab := state.ActiveTab()
+	if tab.PreparedTx == nil {
 		return
 	}
+	tx, err := eth.WithFee(tab.PreparedTx, a.Fee)
+	if err != nil {
+		tab.AppErrorText = err.Error()
+	} else {
+		if a.Gas != 0 {
+			tx = eth.WithGas(tx, a.Gas)
+		}
+		tab.PreparedTx = tx
+		tab.FeeLabel = a.Label
+		tab.AppErrorText = ""
+	}
+	tab.ShowFees = false
 
-	log.Printf("act reloadTxState got receipt %s %+v", tx.Hash(), receipt)
+	render()
+}
 
-	// Transaction confirmed or reverted
-	state.Tab.PendingTx = nil
-	if receipt.Status == 0 {
-		state.Tab.ErrorText = fmt.Sprintf("transaction reverted: %s", tx.Hash())
+// Drops the remaining transactions in a batch.
+func abortTxs(tab *TabState) {
+	if len(tab.ProposedTxs) > 0 {
+		log.Printf("act aborting %d remaining transactions", len(tab.ProposedTxs))
+	}
+	tab.ProposedTxs = nil
+	tab.PreparedTx = nil
+	tab.FeeOptions = nil
+	tab.FeeLabel = ""
+	tab.ShowFees = false
+	tab.TxStep = 0
+	tab.TxCount = 0
+}
+
+// Unlock the signer, eg decrypt a keystore file, with a password.
+type ActUnlock struct {
+	Password string
+}
+
+func (a *ActUnlock) Run() {
+	ls, ok := state.Chain.Signer.(eth.LockableSigner)
+	if !ok {
+		return
+	}
+	err := ls.Unlock(a.Password)
+	if err != nil {
+		state.Chain.UnlockErrorText = err.Error()
+		render()
+		return
+	}
+
+	state.Chain.Locked = false
+	state.Chain.UnlockPrompt = false
+	state.Chain.UnlockErrorText = ""
+
+	// Fill in gas and fees for a transaction proposed while locked.
+	tab := state.ActiveTab()
+	if len(tab.ProposedTxs) > 0 && tab.PreparedTx == nil && tab.PendingTx == nil {
+		prepareNextTx(tab, 0, true)
+	}
+
+	render()
+}
+
+// Don't log passwords.
+func (a *ActUnlock) GoString() string {
+	return "&act.ActUnlock{Password:<redacted>}"
+}
+
+// Clear the decrypted key from memory. Keeps the address.
+type ActLock struct {
+}
+
+func (a *ActLock) Run() {
+	ls, ok := state.Chain.Signer.(eth.LockableSigner)
+	if !ok || state.Chain.Locked {
+		return
+	}
+	ls.Lock()
+	state.Chain.Locked = true
+
+	render()
+}
+
+// Show or hide the password prompt.
+type ActShowUnlock struct {
+	Show bool
+}
+
+func (a *ActShowUnlock) Run() {
+	state.Chain.UnlockPrompt = a.Show && state.Chain.Locked
+	state.Chain.UnlockErrorText = ""
+
+	render()
+}
+
+// Switch the logged-in account. Re-renders the current tab, since the
+// frontend may show different things for a different from address.
+type ActSwitchAccount struct {
+	Ix int
+}
+
+func (a *ActSwitchAccount) Run() {
+	if a.Ix < 0 || a.Ix >= len(keyOpts.Accounts) || a.Ix == state.Chain.AccountIx {
+		return
+	}
+	state.Chain.AccountIx = a.Ix
+	setSigner(keyOpts.Accounts[a.Ix])
+
+	// Proposed transactions are from the old account. Pending ones stay.
+	for i := range state.Tabs {
+		abortTxs(&state.Tabs[i])
+	}
+
+	render()
+	reloadAccountState()
+	reloadTab(state.ActiveTab())
+}
+
+// Open a new, empty tab and switch to it.
+type ActNewTab struct {
+}
+
+func (a *ActNewTab) Run() {
+	state.Tabs = append(state.Tabs, newTab())
+	state.TabIx = len(state.Tabs) - 1
+
+	render()
+}
+
+// Close a tab. Closing the last tab leaves a single empty one.
+type ActCloseTab struct {
+	Ix int
+}
+
+func (a *ActCloseTab) Run() {
+	if a.Ix < 0 || a.Ix >= len(state.Tabs) {
+		return
+	}
+	state.Tabs = append(state.Tabs[:a.Ix], state.Tabs[a.Ix+1:]...)
+	if len(state.Tabs) == 0 {
+		state.Tabs = []TabState{newTab()}
+	}
+	if state.TabIx > a.Ix || state.TabIx >= len(state.Tabs) {
+		state.TabIx--
+	}
+	if state.TabIx < 0 {
+		state.TabIx = 0
 	}
 
 	render()
 }
 
+// Switch to a different tab.
+type ActSwitchTab struct {
+	Ix int
+}
+
+func (a *ActSwitchTab) Run() {
+	if a.Ix < 0 || a.Ix >= len(state.Tabs) {
+		return
+	}
+	state.TabIx = a.Ix
+
+	render()
+	if tab := state.ActiveTab(); tab.AutoRefresh {
+		refreshTab(tab)
+	}
+}
+
+// Re-render the active tab now.
+type ActReload struct {
+}
+
+func (a *ActReload) Run() {
+	reloadTab(state.ActiveTab())
+}
+
+// Set the active tab's auto-refresh mode: off, every new block (Every 0),
+// or on a timer.
+type ActSetAutoRefresh struct {
+	Auto  bool
+	Every time.Duration
+}
+
+func (a *ActSetAutoRefresh) Run() {
+	tab := state.ActiveTab()
+	tab.AutoRefresh = a.Auto
+	tab.RefreshEvery = a.Every
+	tab.NoticeText = "Auto-refresh off"
+	if a.Auto && a.Every == 0 {
+		tab.NoticeText = "Auto-refresh every block"
+	} else if a.Auto {
+		tab.NoticeText = fmt.Sprintf("Auto-refresh every %s", a.Every)
+	}
+
+	render()
+}
+
+// Check pending transactions in every tab, not just the active one.
+func reloadTxState() {
+	changed := false
+	for i := range state.Tabs {
+		changed = reloadTabTxState(&state.Tabs[i]) || changed
+	}
+	if changed {
+		render()
+	}
+}
+
+func reloadTabTxState(tab *TabState) bool {
+	if tab.PendingTx == nil {
+		return false
+	}
+	ctx := context.Background()
+
+	// Any of the transactions at this nonce may land: the latest replacement,
+	// or an earlier one that was already propagated.
+	var tx *types.Transaction
+	var receipt *types.Receipt
+	var gasPrice *big.Int
+	var hashes []common.Hash
+	for _, t := range append([]*types.Transaction{tab.PendingTx}, tab.ReplacedTxs...) {
+		hashes = append(hashes, t.Hash())
+		if receipt != nil {
+			continue
+		}
+		r, p, err := client.TransactionReceipt(ctx, t.Hash())
+		if err != nil && err != ethereum.NotFound {
+			log.Printf("act reloadTxState error %v", err)
+		}
+		if r != nil {
+			tx, receipt, gasPrice = t, r, p
+		}
+	}
+	if receipt == nil {
+		return false
+	}
+
+	log.Printf("act reloadTxState got receipt %s %+v", tx.Hash(), receipt)
+	if n := receipt.BlockNumber.Uint64(); n > state.Chain.Head {
+		// Polling hasn't seen this block yet. Don't serve stale renders.
+		state.Chain.Head = n
+		client.SetHead(n)
+	}
+
+	// Transaction confirmed or reverted
+	from := tab.PendingFrom
+	replaced := len(tab.ReplacedTxs) > 0
+	clearPending(tab)
+	if replaced && eth.IsCancelTx(tx, from) {
+		logTxMined(tx, hashes, receipt, gasPrice, TxCancelled)
+		tab.NoticeText = fmt.Sprintf("Cancelled transaction at nonce %d", tx.Nonce())
+		if len(tab.ProposedTxs) > 0 {
+			tab.NoticeText += fmt.Sprintf(", skipped %d remaining", len(tab.ProposedTxs))
+		}
+		abortTxs(tab)
+	} else if receipt.Status == 0 {
+		logTxMined(tx, hashes, receipt, gasPrice, TxReverted)
+		reason, simulated := client.RevertReason(tx, from, receipt.BlockNumber)
+		tab.AppErrorText = fmt.Sprintf("transaction %s reverted: %s", tx.Hash(), reason)
+		if simulated {
+			tab.AppErrorText += " (reason from re-simulation, may differ)"
+		}
+		if len(tab.ProposedTxs) > 0 {
+			tab.AppErrorText += fmt.Sprintf(", skipped %d remaining", len(tab.ProposedTxs))
+		}
+		abortTxs(tab)
+	} else {
+		logTxMined(tx, hashes, receipt, gasPrice, TxSuccess)
+		if len(tab.ProposedTxs) > 0 {
+			// Batch continues. Estimate gas only now, since eg a swap can only
+			// succeed once the preceding approve has landed.
+			tab.TxStep++
+			prepareNextTx(tab, tx.Nonce()+1, true)
+		}
+	}
+	return true
+}
+
 // Reload context information about the blockchain.
 func reloadChainState() {
 	state.Chain.Conn = client.ConnStatus()
 
+	ctx := context.Background()
+	for i := range state.Chain.Accounts {
+		acc := &state.Chain.Accounts[i]
+		// Same cached lookup as the logged-in account's
+		acc.Addr = client.ReverseResolve(acc.Addr.Addr)
+		bal, err := client.Ec.BalanceAt(ctx, acc.Addr.Addr, nil)
+		if err != nil {
+			log.Printf("act reloadChainState balance %s error %v", acc.Addr.Addr, err)
+			continue
+		}
+		acc.Balance = bal
+	}
+	reloadAccountState()
+
 	render()
 }
 
-func reloadTab() {
-	if state.Tab.ContractAddr == nil {
+// Address whose reverse ENS name is in state.Chain.Account
+var reverseResolvedAddr common.Address
+
+// Reload ENS name, balance, nonce and token balances of the logged-in account.
+func reloadAccountState() {
+	chain := &state.Chain
+	addr := chain.Account.Addr
+	if eth.IsZeroAddr(addr) {
+		return
+	}
+	ctx := context.Background()
+
+	// Reverse ENS, once per account
+	if addr != reverseResolvedAddr {
+		reverseResolvedAddr = addr
+		chain.Account = client.ReverseResolve(addr)
+	}
+
+	bal, err := client.Ec.BalanceAt(ctx, addr, nil)
+	if err != nil {
+		log.Printf("act reloadAccountState balance error %v", err)
+	}
+	chain.Balance = bal
+	nonce, err := client.Ec.PendingNonceAt(ctx, addr)
+	if err != nil {
+		log.Printf("act reloadAccountState nonce error %v", err)
+	}
+	chain.Nonce = nonce
+
+	for i := range chain.Tokens {
+		tok := &chain.Tokens[i]
+		if tok.Info == nil {
+			tok.Info, err = client.TokenInfo(ctx, tok.Addr)
+			if err != nil {
+				log.Printf("act reloadAccountState token error %v", err)
+				continue
+			}
+		}
+		tok.Balance, err = client.TokenBalance(ctx, tok.Addr, addr)
+		if err != nil {
+			log.Printf("act reloadAccountState token %s balance error %v", tok.Info.Symbol, err)
+		}
+	}
+}
+
+func reloadTab(tab *TabState) {
+	if tab.ContractAddr == nil {
 		return
 	}
 
-	appState := []byte{}
 	vdom, err := client.FrontendRender(
-		state.Chain.Account.Addr, *state.Tab.ContractAddr, appState)
+		state.Chain.Account.Addr, *tab.ContractAddr, tab.AppState)
 	if err == nil {
-		state.Tab.Vdom = vdom
-		state.Tab.ErrorText = ""
+		tab.Vdom = vdom
+		tab.ErrorText = ""
 
 		maxId := uint8(0)
-		for _, v := range state.Tab.Vdom {
+		for _, v := range tab.Vdom {
 			if v.DataElem.GetKey() > maxId {
 				maxId = v.DataElem.GetKey()
 			}
 		}
-		state.Tab.Inputs = make([][]byte, maxId+1)
+		// Keep inputs, eg when restoring from history
+		inputs := make([][]byte, maxId+1)
+		copy(inputs, tab.Inputs)
+		tab.Inputs = inputs
 	} else {
-		state.Tab.Vdom = nil
-		state.Tab.ErrorText = err.Error()
+		tab.Vdom = nil
+		tab.ErrorText = err.Error()
 	}
 
 	render()
 }
 
 func render() {
-	renderer(&state)
+	renderer(state.snapshot())
 }
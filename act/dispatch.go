@@ -1,36 +1,96 @@
 package act
 
 import (
-	"crypto/ecdsa"
+	"context"
 	"log"
 	"time"
 
 	"dcposch.eth/cli/eth"
-	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 )
 
 var (
-	client   *eth.Client
-	state    State
-	renderer func(*State)
-	queue    chan Action
+	client     *eth.Client
+	keyOpts    KeyOpts
+	opts       Opts
+	state      State
+	renderer   func(*State)
+	queue      chan Action
+	lastActive time.Time
 )
 
-func Init(_client *eth.Client, _privateKey *ecdsa.PrivateKey, _renderer func(*State)) {
+// Account key configuration, from the command line.
+type KeyOpts struct {
+	// Signs transactions. Nil if not logged in.
+	Signer eth.Signer
+	// Accounts the user can switch between, eg derived from a mnemonic.
+	// Empty for a single account.
+	Accounts []eth.Signer
+	// Lock a LockableSigner after this long without user activity
+	LockAfter time.Duration
+	// Watch-only address, used when there's no signer
+	WatchAddr common.Address
+	// Where to write unsigned transactions for offline signing
+	ExportDir string
+}
+
+// Browser options, from the command line.
+type Opts struct {
+	// Auto-refresh new tabs on each new block
+	RefreshOnBlock bool
+	// Auto-refresh new tabs on a timer instead. 0 to disable.
+	RefreshEvery time.Duration
+	// ERC-20 tokens to show balances for
+	Tokens []common.Address
+}
+
+func Init(_client *eth.Client, _keyOpts KeyOpts, _opts Opts, _renderer func(*State)) {
 	client = _client
+	keyOpts = _keyOpts
+	opts = _opts
 	renderer = _renderer
 	queue = make(chan Action, 1)
-	setPrivateKey(_privateKey)
+	state.Tabs = []TabState{newTab()}
+	loadTxLog()
+	lastActive = time.Now()
+	state.Chain.Account = eth.NamedAddr{Addr: keyOpts.WatchAddr}
+	setSigner(keyOpts.Signer)
+	for _, s := range keyOpts.Accounts {
+		state.Chain.Accounts = append(state.Chain.Accounts, AccountState{Addr: eth.NamedAddr{Addr: s.Address()}})
+	}
+	for _, t := range opts.Tokens {
+		state.Chain.Tokens = append(state.Chain.Tokens, TokenState{Addr: t})
+	}
 
 	go run()
 }
 
-func setPrivateKey(prv *ecdsa.PrivateKey) {
-	state.Chain.PrivateKey = prv
-	if prv != nil {
-		pub := crypto.PubkeyToAddress(prv.PublicKey)
-		state.Chain.Account.Addr = pub
-		log.Printf("recovering address from privkey %s %s", pub, state.Chain.Account.Addr)
+func setSigner(signer eth.Signer) {
+	state.Chain.Signer = signer
+	if signer == nil {
+		return
+	}
+	state.Chain.Account = eth.NamedAddr{Addr: signer.Address()}
+	log.Printf("using signer %T for %s", signer, state.Chain.Account.Addr)
+
+	if ls, ok := signer.(eth.LockableSigner); ok && ls.Locked() {
+		state.Chain.Locked = true
+		state.Chain.UnlockPrompt = true
+	}
+}
+
+// Forget the decrypted key after LockAfter of inactivity.
+func lockIfIdle() {
+	if keyOpts.LockAfter == 0 || state.Chain.Locked {
+		return
+	}
+	if _, ok := state.Chain.Signer.(eth.LockableSigner); !ok {
+		return
+	}
+	if time.Since(lastActive) > keyOpts.LockAfter {
+		log.Printf("act locking account after %s idle", keyOpts.LockAfter)
+		(&ActLock{}).Run()
 	}
 }
 
@@ -45,17 +105,88 @@ func Dispatch(a Action) {
 
 func run() {
 	reloadChainState()
+	reloadTxLog()
 
+	// Over websockets, new blocks drive reloads. Over HTTP, or while the
+	// subscription is down, poll.
+	var heads <-chan *types.Header
 	tickChainState := time.NewTicker(time.Second * 10)
 	tickTxState := time.NewTicker(time.Second * 2)
+	tickRefresh := time.NewTicker(time.Second)
+	if client.IsWebsocket() {
+		heads = client.SubscribeHeads()
+	}
+	polling := func() bool {
+		return heads == nil || !client.HeadsLive()
+	}
 	for {
 		select {
 		case a := <-queue:
+			lastActive = time.Now()
 			a.Run()
+		case h := <-heads:
+			onNewBlock(h.Number.Uint64())
 		case <-tickChainState.C:
-			reloadChainState()
+			lockIfIdle()
+			if polling() {
+				pollNewBlock()
+			}
 		case <-tickTxState.C:
-			reloadTxState()
+			if polling() {
+				reloadTxState()
+			}
+		case <-tickRefresh.C:
+			tab := state.ActiveTab()
+			if tab.AutoRefresh && tab.RefreshEvery > 0 && time.Since(tab.RefreshedAt) >= tab.RefreshEvery {
+				refreshTab(tab)
+			}
 		}
 	}
 }
+
+// Polls for a new block, for RPC connections without subscriptions.
+func pollNewBlock() {
+	head, err := client.Ec.BlockNumber(context.Background())
+	if err != nil {
+		log.Printf("act pollNewBlock error %v", err)
+		reloadChainState()
+		return
+	}
+	if head == state.Chain.Head {
+		reloadChainState()
+		return
+	}
+	onNewBlock(head)
+}
+
+func onNewBlock(head uint64) {
+	state.Chain.Head = head
+	client.SetHead(head)
+	reloadChainState()
+	reloadTxState()
+	reloadTxLog()
+
+	tab := state.ActiveTab()
+	if tab.AutoRefresh && tab.RefreshEvery == 0 {
+		refreshTab(tab)
+	}
+}
+
+// Re-renders a tab for auto-refresh. Only the active tab refreshes; others
+// catch up when switched to.
+func refreshTab(tab *TabState) {
+	tab.RefreshedAt = time.Now()
+	// Don't re-render under a transaction confirmation.
+	if len(tab.ProposedTxs) > 0 || tab.PendingTx != nil {
+		return
+	}
+	reloadTab(tab)
+}
+
+// A new, empty tab with the default auto-refresh mode
+func newTab() TabState {
+	return TabState{
+		AutoRefresh:  opts.RefreshOnBlock || opts.RefreshEvery > 0,
+		RefreshEvery: opts.RefreshEvery,
+	}
+}
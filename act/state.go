@@ -1,7 +1,8 @@
 package act
 
 import (
-	"crypto/ecdsa"
+	"math/big"
+	"time"
 
 	"dcposch.eth/cli/eth"
 	"github.com/ethereum/go-ethereum"
@@ -11,18 +12,114 @@ import (
 
 // Browser state
 type State struct {
-	Tab   TabState
+	// Open tabs, in tab bar order. Always at least one.
+	Tabs []TabState
+	// Index of the active tab
+	TabIx int
 	Chain ChainState
+	// Import page for pre-signed transactions
+	Import ImportState
+	// Transactions sent from ethcli, oldest first. Persisted across runs.
+	TxLog []TxRecord
+}
+
+// Pre-signed raw transaction, pasted or loaded for broadcast
+type ImportState struct {
+	// show the import page
+	Show bool
+	// decoded transaction, shown for review
+	Tx *types.Transaction
+	// sender, recovered from the signature
+	From common.Address
+	// invalid encoding, wrong chain, etc
+	ErrorText string
+}
+
+// True if we can sign transactions right now
+func (c *ChainState) CanSign() bool {
+	return c.Signer != nil && !c.Locked
+}
+
+// True if browsing as an address without a key. Transactions can be
+// prepared and exported for offline signing, but not sent.
+func (c *ChainState) IsWatchOnly() bool {
+	return c.Signer == nil && !eth.IsZeroAddr(c.Account.Addr)
+}
+
+// True if we can speed up or cancel the tab's pending transaction
+func (s *State) CanReplace(tab *TabState) bool {
+	return tab.PendingTx != nil && s.Chain.CanSign() && tab.PendingFrom == s.Chain.Account.Addr
+}
+
+// Returns the tab currently shown
+func (s *State) ActiveTab() *TabState {
+	return &s.Tabs[s.TabIx]
+}
+
+// Returns a copy that later actions won't modify, so the UI goroutine can
+// render it while the act goroutine moves on. Actions update slice elements
+// in place, so each slice is copied.
+func (s *State) snapshot() *State {
+	ret := *s
+	ret.Tabs = make([]TabState, len(s.Tabs))
+	for i, t := range s.Tabs {
+		t.Vdom = append([]eth.VElem(nil), t.Vdom...)
+		t.Inputs = append([][]byte(nil), t.Inputs...)
+		t.ProposedTxs = append([]ethereum.CallMsg(nil), t.ProposedTxs...)
+		t.ReplacedTxs = append([]*types.Transaction(nil), t.ReplacedTxs...)
+		t.Back = append([]HistoryEntry(nil), t.Back...)
+		t.Forward = append([]HistoryEntry(nil), t.Forward...)
+		ret.Tabs[i] = t
+	}
+	ret.Chain.Tokens = append([]TokenState(nil), s.Chain.Tokens...)
+	ret.Chain.Accounts = append([]AccountState(nil), s.Chain.Accounts...)
+	ret.TxLog = append([]TxRecord(nil), s.TxLog...)
+	return &ret
 }
 
 // Ethereum chain connection state
 type ChainState struct {
-	// logged-in account key
-	PrivateKey *ecdsa.PrivateKey
+	// logged-in account signer: in-memory key, keystore, or external signer
+	Signer eth.Signer
+	// signer needs a password. Key not decrypted yet, or cleared after idle.
+	Locked bool
+	// show the password prompt
+	UnlockPrompt bool
+	// wrong password, etc
+	UnlockErrorText string
 	// logged-in account, eg vitalik.eth
 	Account eth.NamedAddr
+	// native balance of Account in wei, nil if not loaded yet
+	Balance *big.Int
+	// pending nonce of Account
+	Nonce uint64
+	// ERC-20 balances of Account, for the tokens passed via --tokens
+	Tokens []TokenState
+	// accounts to pick from, eg derived from a mnemonic. Empty for a single key.
+	Accounts []AccountState
+	// index of the logged-in account in Accounts
+	AccountIx int
 	// connection status, chain ID, etc
 	Conn eth.ConnStatus
+	// latest block number, 0 if not known yet
+	Head uint64
+}
+
+// An account in the account picker
+type AccountState struct {
+	// Address, with reverse ENS name
+	Addr eth.NamedAddr
+	// Balance in wei, nil if not loaded yet
+	Balance *big.Int
+}
+
+// A watched ERC-20 token
+type TokenState struct {
+	Addr common.Address
+	// symbol and decimals, nil if not loaded yet
+	Info *eth.TokenInfo
+	// balance in base units, nil if not loaded yet
+	Balance *big.Int
 }
 
 // Tab state
@@ -31,16 +128,71 @@ type TabState struct {
 	EnteredAddr string
 	// Resolved contract addresss
 	ContractAddr *common.Address
+	// Same, with ENS name: as entered, or via reverse lookup
+	Contract eth.NamedAddr
 	// Error loading the app
 	ErrorText string
 	// Error within the app
 	AppErrorText string
+	// Status message, eg "exported unsigned tx to ..."
+	NoticeText string
+	// Opaque app state, as returned by the contract act(). Passed to render().
+	AppState []byte
 	// The displayed app, as returned by the contract render()
 	Vdom []eth.VElem
 	// ABI-encoded user inputs. Inputs[k] == nil if user hasn't entered anything for key k.
 	Inputs [][]byte
-	// Shows confirmation modal.
-	ProposedTx *ethereum.CallMsg
+	// Queue of transactions proposed by the app, not yet sent. Shows
+	// confirmation modal. Sent one at a time, each after the last confirms.
+	ProposedTxs []ethereum.CallMsg
+	// Unsigned transaction for ProposedTxs[0], with nonce, gas and fees filled in.
+	PreparedTx *types.Transaction
+	// Chain ID PreparedTx is for. An unsigned legacy tx doesn't record it.
+	PreparedChainID *big.Int
+	// Recipient of PreparedTx, with reverse ENS name
+	PreparedTo eth.NamedAddr
+	// Fee presets for PreparedTx
+	FeeOptions *eth.FeeOptions
+	// Chosen fee: "slow", "normal", "fast" or "custom"
+	FeeLabel string
+	// Show the fee editor
+	ShowFees bool
+	// Batch progress. ProposedTxs[0] is step TxStep of TxCount, eg 1 of 2.
+	TxStep  int
+	TxCount int
 	// Sent transaction, waiting for block confirmation.
 	PendingTx *types.Transaction
+	// Sender of PendingTx
+	PendingFrom common.Address
+	// Earlier transactions at the same nonce, replaced by PendingTx via speed
+	// up or cancel. Any of them may still land instead.
+	ReplacedTxs []*types.Transaction
+	// Re-render on new blocks or on a timer, so balances and quotes stay current
+	AutoRefresh bool
+	// Auto-refresh interval. 0 means every new block.
+	RefreshEvery time.Duration
+	// Time of the last auto-refresh
+	RefreshedAt time.Time
+	// Navigation history. Most recent last.
+	Back    []HistoryEntry
+	Forward []HistoryEntry
+}
+
+// A previously visited page, restored via back/forward.
+type HistoryEntry struct {
+	EnteredAddr  string
+	ContractAddr *common.Address
+	Contract     eth.NamedAddr
+	AppState     []byte
+	Inputs       [][]byte
+}
+
+func (t *TabState) historyEntry() HistoryEntry {
+	return HistoryEntry{
+		EnteredAddr:  t.EnteredAddr,
+		ContractAddr: t.ContractAddr,
+		Contract:     t.Contract,
+		AppState:     t.AppState,
+		Inputs:       t.Inputs,
+	}
 }
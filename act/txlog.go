@@ -0,0 +1,253 @@
+package act
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"dcposch.eth/cli/eth"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Status of a sent transaction
+const (
+	TxPending   = "pending"
+	TxSuccess   = "success"
+	TxReverted  = "reverted"
+	TxCancelled = "cancelled"
+)
+
+// A transaction sent from ethcli, kept in the local history log.
+type TxRecord struct {
+	Hash common.Hash
+	// Other transactions at this nonce, replaced via speed up or cancel. Any
+	// of them may land instead of Hash.
+	Replaced []common.Hash
+	ChainID  int64
+	From     common.Address
+	To       *common.Address
+	// To, with reverse ENS name as of sending
+	ToName eth.NamedAddr
+	// Frontend contract that proposed the transaction. Nil for imported ones.
+	Frontend *common.Address
+	// Decoded function name, eg "approve"
+	Call   string
+	Value  *big.Int
+	Nonce  uint64
+	Status string
+	// Filled in once mined
+	GasUsed           uint64
+	EffectiveGasPrice *big.Int
+	Block             uint64
+	SentAt            time.Time
+}
+
+// Transaction log file under the user config dir, eg ~/.config/ethcli/txlog.json
+func txLogPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ethcli", "txlog.json"), nil
+}
+
+func loadTxLog() {
+	path, err := txLogPath()
+	if err != nil {
+		log.Printf("act loadTxLog %v", err)
+		return
+	}
+	js, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	} else if err != nil {
+		log.Printf("act loadTxLog %v", err)
+		return
+	}
+	if err := json.Unmarshal(js, &state.TxLog); err != nil {
+		log.Printf("act loadTxLog %s invalid: %v", path, err)
+	}
+}
+
+func saveTxLog() {
+	err := func() error {
+		path, err := txLogPath()
+		if err != nil {
+			return err
+		}
+		js, err := json.MarshalIndent(state.TxLog, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return err
+		}
+		// Write then rename, so a crash never leaves a truncated log.
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, js, 0600); err != nil {
+			return err
+		}
+		return os.Rename(tmp, path)
+	}()
+	if err != nil {
+		log.Printf("act saveTxLog %v", err)
+	}
+}
+
+// Records a newly sent transaction.
+func logTxSent(tab *TabState, tx *types.Transaction, from common.Address) {
+	var toName eth.NamedAddr
+	if tx.To() != nil {
+		toName = client.ReverseResolve(*tx.To())
+	}
+	state.TxLog = append(state.TxLog, TxRecord{
+		Hash:     tx.Hash(),
+		ChainID:  state.Chain.Conn.ChainID,
+		From:     from,
+		To:       tx.To(),
+		ToName:   toName,
+		Frontend: tab.ContractAddr,
+		Call:     eth.DecodeFunctionName(tx.Data()),
+		Value:    tx.Value(),
+		Nonce:    tx.Nonce(),
+		Status:   TxPending,
+		SentAt:   time.Now(),
+	})
+	saveTxLog()
+}
+
+// Records a replacement. The log keeps one entry per nonce, tracking
+// whichever transaction was sent last, plus the ones it replaced.
+func logTxReplaced(old, tx *types.Transaction) {
+	rec := findTxRecord(old.Hash())
+	if rec == nil {
+		return
+	}
+	rec.Replaced = append(rec.Replaced, rec.Hash)
+	rec.Hash = tx.Hash()
+	saveTxLog()
+}
+
+// Records the outcome of a mined transaction. prevHashes are the other
+// transactions at the same nonce, any of which the log may be tracking.
+func logTxMined(tx *types.Transaction, prevHashes []common.Hash, receipt *types.Receipt, gasPrice *big.Int, status string) {
+	rec := findTxRecord(tx.Hash())
+	for i := 0; rec == nil && i < len(prevHashes); i++ {
+		rec = findTxRecord(prevHashes[i])
+	}
+	if rec == nil {
+		return
+	}
+	if gasPrice == nil && tx.Type() != types.DynamicFeeTxType {
+		gasPrice = tx.GasPrice()
+	}
+	if tx.To() == nil || rec.To == nil || *tx.To() != *rec.To {
+		// A cancellation landed instead, sent to self
+		rec.ToName = eth.NamedAddr{}
+		if tx.To() != nil {
+			rec.ToName.Addr = *tx.To()
+		}
+	}
+	rec.setLanded(tx.Hash())
+	rec.To = tx.To()
+	rec.Call = eth.DecodeFunctionName(tx.Data())
+	rec.Value = tx.Value()
+	rec.Status = status
+	rec.GasUsed = receipt.GasUsed
+	rec.EffectiveGasPrice = gasPrice
+	if receipt.BlockNumber != nil {
+		rec.Block = receipt.BlockNumber.Uint64()
+	}
+	saveTxLog()
+}
+
+// Makes the transaction that landed the record's Hash. The rest stay in
+// Replaced.
+func (r *TxRecord) setLanded(hash common.Hash) {
+	if hash == r.Hash {
+		return
+	}
+	var replaced []common.Hash
+	for _, h := range append(r.Replaced, r.Hash) {
+		if h != hash {
+			replaced = append(replaced, h)
+		}
+	}
+	r.Hash = hash
+	r.Replaced = replaced
+}
+
+// All transactions at the record's nonce, latest first
+func (r *TxRecord) hashes() []common.Hash {
+	ret := []common.Hash{r.Hash}
+	for i := len(r.Replaced) - 1; i >= 0; i-- {
+		ret = append(ret, r.Replaced[i])
+	}
+	return ret
+}
+
+// Finds the record for a transaction or any of its replacements.
+func findTxRecord(hash common.Hash) *TxRecord {
+	for i := len(state.TxLog) - 1; i >= 0; i-- {
+		for _, h := range state.TxLog[i].hashes() {
+			if h == hash {
+				return &state.TxLog[i]
+			}
+		}
+	}
+	return nil
+}
+
+// Checks pending transactions that no tab is waiting for, eg left by a
+// previous run, or a tab that hid or closed. Runs on startup and each block.
+func reloadTxLog() {
+	ctx := context.Background()
+	changed := false
+	for i := range state.TxLog {
+		rec := &state.TxLog[i]
+		if rec.Status != TxPending || rec.ChainID != state.Chain.Conn.ChainID || isTracked(rec.Hash) {
+			continue
+		}
+		// The latest replacement, or any earlier transaction, may land
+		hashes := rec.hashes()
+		for _, hash := range hashes {
+			receipt, gasPrice, err := client.TransactionReceipt(ctx, hash)
+			if err != nil {
+				continue
+			}
+			tx, _, err := client.Ec.TransactionByHash(ctx, hash)
+			if err != nil {
+				log.Printf("act reloadTxLog %s %v", hash, err)
+				break
+			}
+			status := TxSuccess
+			if len(hashes) > 1 && eth.IsCancelTx(tx, rec.From) {
+				status = TxCancelled
+			} else if receipt.Status == 0 {
+				status = TxReverted
+			}
+			logTxMined(tx, hashes, receipt, gasPrice, status)
+			changed = true
+			break
+		}
+	}
+	if changed {
+		render()
+	}
+}
+
+// True if a tab is waiting for this transaction
+func isTracked(hash common.Hash) bool {
+	for _, tab := range state.Tabs {
+		if tab.PendingTx != nil && tab.PendingTx.Hash() == hash {
+			return true
+		}
+	}
+	return false
+}
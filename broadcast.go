@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"dcposch.eth/cli/eth"
+)
+
+// Runs a subcommand instead of the browser. Returns the exit code.
+func runCommand(client *eth.Client, command []string) int {
+	switch command[0] {
+	case "broadcast":
+		if len(command) != 2 {
+			fmt.Println("Usage: ethcli broadcast <signed tx hex or file>")
+			return 2
+		}
+		return broadcast(client, command[1])
+	default:
+		return 2
+	}
+}
+
+// Decodes a pre-signed transaction, asks for confirmation, sends it and
+// waits for the receipt.
+func broadcast(client *eth.Client, arg string) int {
+	raw := []byte(arg)
+	if _, err := os.Stat(arg); err == nil {
+		raw, err = os.ReadFile(arg)
+		if err != nil {
+			fmt.Println(err)
+			return 1
+		}
+	}
+	tx, from, err := eth.DecodeSignedTx(raw)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	fmt.Println(strings.Join(eth.SignedTxSummary(tx, from), "\n"))
+	conn := client.ConnStatus()
+	if conn.ErrorText != "" {
+		fmt.Println("Disconnected:", conn.ErrorText)
+		return 1
+	}
+	if tx.Protected() && tx.ChainId().Int64() != conn.ChainID {
+		fmt.Printf("Transaction is for chain %s, connected to %d\n", tx.ChainId(), conn.ChainID)
+		return 1
+	}
+
+	fmt.Printf("\nBroadcast to %s? [y/N] ", conn.ChainName)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+		fmt.Println("Cancelled")
+		return 1
+	}
+
+	ctx := context.Background()
+	if err := client.Ec.SendTransaction(ctx, tx); err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	fmt.Printf("Sent %s, waiting for confirmation...\n", tx.Hash())
+
+	for {
+		time.Sleep(2 * time.Second)
+		receipt, err := client.Ec.TransactionReceipt(ctx, tx.Hash())
+		if err != nil || receipt == nil {
+			continue
+		}
+		if receipt.Status == 0 {
+			fmt.Printf("Reverted in block %s\n", receipt.BlockNumber)
+			return 1
+		}
+		fmt.Printf("Confirmed in block %s, gas used %d\n", receipt.BlockNumber, receipt.GasUsed)
+		return 0
+	}
+}
@@ -0,0 +1,238 @@
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Beacon chain constants for the light client protocol
+const (
+	slotsPerEpoch       = 32
+	slotsPerPeriod      = 32 * 256
+	syncCommitteeSize   = 512
+	slotSeconds         = 12
+	domainSyncCommittee = 0x07
+	// Max updates per light_client/updates request
+	maxUpdatesPerRequest = 128
+)
+
+// Merkle proof positions. Sync committees are fields of the beacon state;
+// the finalized root is one level deeper, within the finalized checkpoint.
+const (
+	stateIndexCurrentCommittee = 22
+	stateIndexNextCommittee    = 23
+	stateIndexFinalizedRoot    = 41
+	bodyIndexExecution         = 9
+	bodyDepth                  = 4
+)
+
+// A consensus network: genesis and fork schedule, which fix signing domains.
+type beaconNetwork struct {
+	genesisTime           uint64
+	genesisValidatorsRoot common.Hash
+	// Fork versions and activation epochs, oldest first
+	forks []beaconFork
+}
+
+type beaconFork struct {
+	name    string
+	epoch   uint64
+	version [4]byte
+}
+
+// Networks by execution chain ID. Later forks must be added here as they
+// are scheduled, since each one changes the signing domain.
+var beaconNetworks = map[int64]*beaconNetwork{
+	1: {
+		genesisTime:           1606824023,
+		genesisValidatorsRoot: common.HexToHash("0x4b363db94e286120d76eb905340fdd4e54bfe9f06bf33ff6cf5ad27f511bfe95"),
+		forks: []beaconFork{
+			{"phase0", 0, [4]byte{0, 0, 0, 0}},
+			{"altair", 74240, [4]byte{1, 0, 0, 0}},
+			{"bellatrix", 144896, [4]byte{2, 0, 0, 0}},
+			{"capella", 194048, [4]byte{3, 0, 0, 0}},
+			{"deneb", 269568, [4]byte{4, 0, 0, 0}},
+			{"electra", 364032, [4]byte{5, 0, 0, 0}},
+			{"fulu", 411392, [4]byte{6, 0, 0, 0}},
+		},
+	},
+	11155111: {
+		genesisTime:           1655733600,
+		genesisValidatorsRoot: common.HexToHash("0xd8ea171f3c94aea21ebc42a1ed61052acf3f9209c00e4efbaaddac09ed9b8078"),
+		forks: []beaconFork{
+			{"phase0", 0, [4]byte{0x90, 0, 0, 0x69}},
+			{"altair", 50, [4]byte{0x90, 0, 0, 0x70}},
+			{"bellatrix", 100, [4]byte{0x90, 0, 0, 0x71}},
+			{"capella", 56832, [4]byte{0x90, 0, 0, 0x72}},
+			{"deneb", 132608, [4]byte{0x90, 0, 0, 0x73}},
+			{"electra", 222464, [4]byte{0x90, 0, 0, 0x74}},
+			{"fulu", 272640, [4]byte{0x90, 0, 0, 0x75}},
+		},
+	},
+}
+
+// Active fork at a given slot
+func (n *beaconNetwork) forkAt(slot uint64) beaconFork {
+	epoch := slot / slotsPerEpoch
+	ret := n.forks[0]
+	for _, f := range n.forks {
+		if f.epoch <= epoch {
+			ret = f
+		}
+	}
+	return ret
+}
+
+// True if the given fork is active at slot
+func (n *beaconNetwork) isActive(fork string, slot uint64) bool {
+	for _, f := range n.forks {
+		if f.name == fork {
+			return f.epoch <= slot/slotsPerEpoch
+		}
+	}
+	return false
+}
+
+// Depth of the beacon state tree. Electra added fields, doubling it.
+func (n *beaconNetwork) stateDepth(slot uint64) int {
+	if n.isActive("electra", slot) {
+		return 6
+	}
+	return 5
+}
+
+// Current slot per the wall clock
+func (n *beaconNetwork) currentSlot(now uint64) uint64 {
+	if now < n.genesisTime {
+		return 0
+	}
+	return (now - n.genesisTime) / slotSeconds
+}
+
+// Signing root for a sync committee signature over a header root. The fork
+// is that of the slot before the signature.
+func (n *beaconNetwork) syncSigningRoot(headerRoot common.Hash, signatureSlot uint64) common.Hash {
+	if signatureSlot > 0 {
+		signatureSlot--
+	}
+	fork := n.forkAt(signatureSlot)
+	var version common.Hash
+	copy(version[:], fork.version[:])
+	forkDataRoot := sszHashPair(version, n.genesisValidatorsRoot)
+
+	var domain common.Hash
+	domain[0] = domainSyncCommittee
+	copy(domain[4:], forkDataRoot[:28])
+	return sszHashPair(headerRoot, domain)
+}
+
+// Beacon API types. Numbers come as decimal strings.
+
+type decUint64 uint64
+
+func (d *decUint64) UnmarshalJSON(b []byte) error {
+	v, err := strconv.ParseUint(strings.Trim(string(b), `"`), 10, 64)
+	*d = decUint64(v)
+	return err
+}
+
+type decBig big.Int
+
+func (d *decBig) UnmarshalJSON(b []byte) error {
+	if _, ok := (*big.Int)(d).SetString(strings.Trim(string(b), `"`), 10); !ok {
+		return fmt.Errorf("invalid decimal %s", b)
+	}
+	return nil
+}
+
+type beaconHeader struct {
+	Slot          decUint64   `json:"slot"`
+	ProposerIndex decUint64   `json:"proposer_index"`
+	ParentRoot    common.Hash `json:"parent_root"`
+	StateRoot     common.Hash `json:"state_root"`
+	BodyRoot      common.Hash `json:"body_root"`
+}
+
+type executionHeader struct {
+	ParentHash       common.Hash    `json:"parent_hash"`
+	FeeRecipient     common.Address `json:"fee_recipient"`
+	StateRoot        common.Hash    `json:"state_root"`
+	ReceiptsRoot     common.Hash    `json:"receipts_root"`
+	LogsBloom        hexutil.Bytes  `json:"logs_bloom"`
+	PrevRandao       common.Hash    `json:"prev_randao"`
+	BlockNumber      decUint64      `json:"block_number"`
+	GasLimit         decUint64      `json:"gas_limit"`
+	GasUsed          decUint64      `json:"gas_used"`
+	Timestamp        decUint64      `json:"timestamp"`
+	ExtraData        hexutil.Bytes  `json:"extra_data"`
+	BaseFeePerGas    decBig         `json:"base_fee_per_gas"`
+	BlockHash        common.Hash    `json:"block_hash"`
+	TransactionsRoot common.Hash    `json:"transactions_root"`
+	WithdrawalsRoot  common.Hash    `json:"withdrawals_root"`
+	BlobGasUsed      decUint64      `json:"blob_gas_used"`
+	ExcessBlobGas    decUint64      `json:"excess_blob_gas"`
+}
+
+// Beacon header plus, since Capella, the execution header proven against it
+type lightHeader struct {
+	Beacon          beaconHeader     `json:"beacon"`
+	Execution       *executionHeader `json:"execution"`
+	ExecutionBranch []common.Hash    `json:"execution_branch"`
+}
+
+type syncCommitteeJson struct {
+	Pubkeys         []hexutil.Bytes `json:"pubkeys"`
+	AggregatePubkey hexutil.Bytes   `json:"aggregate_pubkey"`
+}
+
+type syncAggregate struct {
+	Bits      hexutil.Bytes `json:"sync_committee_bits"`
+	Signature hexutil.Bytes `json:"sync_committee_signature"`
+}
+
+type lightBootstrap struct {
+	Header                     lightHeader       `json:"header"`
+	CurrentSyncCommittee       syncCommitteeJson `json:"current_sync_committee"`
+	CurrentSyncCommitteeBranch []common.Hash     `json:"current_sync_committee_branch"`
+}
+
+// Full, finality or optimistic update. The latter two omit some fields.
+type lightUpdate struct {
+	AttestedHeader          lightHeader        `json:"attested_header"`
+	NextSyncCommittee       *syncCommitteeJson `json:"next_sync_committee"`
+	NextSyncCommitteeBranch []common.Hash      `json:"next_sync_committee_branch"`
+	FinalizedHeader         *lightHeader       `json:"finalized_header"`
+	FinalityBranch          []common.Hash      `json:"finality_branch"`
+	SyncAggregate           syncAggregate      `json:"sync_aggregate"`
+	SignatureSlot           decUint64          `json:"signature_slot"`
+}
+
+// Fetches a beacon API path, eg /eth/v1/beacon/genesis, decoding the JSON
+// response into out.
+func beaconGet(ctx context.Context, baseUrl, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimRight(baseUrl, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", path, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("GET %s: %s", path, err)
+	}
+	return nil
+}
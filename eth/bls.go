@@ -0,0 +1,284 @@
+package eth
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/crypto/bls12381"
+)
+
+// BLS signatures as used by the beacon chain: public keys in G1, signatures
+// in G2, proof-of-possession ciphersuite. Built on go-ethereum's BLS12-381
+// arithmetic, which lacks point decompression and hash-to-curve.
+var blsDst = []byte("BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_")
+
+// Base field modulus and derived constants
+var (
+	blsP, _  = new(big.Int).SetString("1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaaab", 16)
+	blsHalfP = new(big.Int).Rsh(blsP, 1)
+	blsSqrtE = new(big.Int).Rsh(new(big.Int).Add(blsP, big.NewInt(1)), 2)
+	blsInv2  = new(big.Int).ModInverse(big.NewInt(2), blsP)
+)
+
+// Checks an aggregate signature by several signers over the same message.
+func blsFastAggregateVerify(pubkeys []*bls12381.PointG1, msg []byte, sig []byte) error {
+	if len(pubkeys) == 0 {
+		return fmt.Errorf("no signers")
+	}
+	sigPoint, err := blsDecodeG2(sig)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %s", err)
+	}
+	g1 := bls12381.NewG1()
+	aggPub := g1.Zero()
+	for _, pk := range pubkeys {
+		g1.Add(aggPub, aggPub, pk)
+	}
+	msgPoint, err := blsHashToG2(msg, blsDst)
+	if err != nil {
+		return err
+	}
+
+	// e(pk, H(m)) == e(g1, sig)
+	engine := bls12381.NewPairingEngine()
+	engine.AddPair(aggPub, msgPoint)
+	engine.AddPairInv(g1.One(), sigPoint)
+	if !engine.Check() {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+// Decodes a compressed public key, rejecting infinity and points outside G1.
+func blsDecodeG1(in []byte) (*bls12381.PointG1, error) {
+	if len(in) != 48 {
+		return nil, fmt.Errorf("want 48 bytes, got %d", len(in))
+	}
+	x, sign, err := blsCompressedCoord(in)
+	if err != nil {
+		return nil, err
+	}
+	// y^2 = x^3 + 4
+	y2 := new(big.Int).Exp(x, big.NewInt(3), blsP)
+	y2.Add(y2, big.NewInt(4)).Mod(y2, blsP)
+	y := fpSqrt(y2)
+	if y == nil {
+		return nil, fmt.Errorf("point not on curve")
+	}
+	if (y.Cmp(blsHalfP) > 0) != sign {
+		y.Sub(blsP, y)
+	}
+
+	uncompressed := make([]byte, 96)
+	x.FillBytes(uncompressed[:48])
+	y.FillBytes(uncompressed[48:])
+	g1 := bls12381.NewG1()
+	p, err := g1.FromBytes(uncompressed)
+	if err != nil {
+		return nil, err
+	}
+	if !g1.InCorrectSubgroup(p) {
+		return nil, fmt.Errorf("point not in G1")
+	}
+	return p, nil
+}
+
+// Decodes a compressed signature, rejecting infinity and points outside G2.
+func blsDecodeG2(in []byte) (*bls12381.PointG2, error) {
+	if len(in) != 96 {
+		return nil, fmt.Errorf("want 96 bytes, got %d", len(in))
+	}
+	// Fp2 elements are encoded c1 || c0
+	x1, sign, err := blsCompressedCoord(in[:48])
+	if err != nil {
+		return nil, err
+	}
+	x0 := new(big.Int).SetBytes(in[48:])
+	if x0.Cmp(blsP) >= 0 {
+		return nil, fmt.Errorf("coordinate out of range")
+	}
+	x := fp2{x0, x1}
+
+	// y^2 = x^3 + 4(1+u)
+	y2 := x.mul(x).mul(x).add(fp2{big.NewInt(4), big.NewInt(4)})
+	y, ok := y2.sqrt()
+	if !ok {
+		return nil, fmt.Errorf("point not on curve")
+	}
+	if y.lexLarger() != sign {
+		y = y.neg()
+	}
+
+	uncompressed := make([]byte, 192)
+	x.fillBytes(uncompressed[:96])
+	y.fillBytes(uncompressed[96:])
+	g2 := bls12381.NewG2()
+	p, err := g2.FromBytes(uncompressed)
+	if err != nil {
+		return nil, err
+	}
+	if !g2.InCorrectSubgroup(p) {
+		return nil, fmt.Errorf("point not in G2")
+	}
+	return p, nil
+}
+
+// Parses the flags and x coordinate of a compressed point.
+func blsCompressedCoord(in []byte) (x *big.Int, sign bool, err error) {
+	flags := in[0]
+	if flags&0x80 == 0 {
+		return nil, false, fmt.Errorf("not compressed")
+	}
+	if flags&0x40 != 0 {
+		return nil, false, fmt.Errorf("point at infinity")
+	}
+	buf := append([]byte{flags & 0x1f}, in[1:]...)
+	x = new(big.Int).SetBytes(buf)
+	if x.Cmp(blsP) >= 0 {
+		return nil, false, fmt.Errorf("coordinate out of range")
+	}
+	return x, flags&0x20 != 0, nil
+}
+
+// Hashes a message to G2 under a domain separation tag, per the
+// hash-to-curve spec: two field elements, each mapped via SSWU, summed.
+// MapToCurve also clears the cofactor, which distributes over the sum.
+func blsHashToG2(msg, dst []byte) (*bls12381.PointG2, error) {
+	g2 := bls12381.NewG2()
+	uniform := expandMessageXmd(msg, dst, 256)
+	ret := g2.Zero()
+	for i := 0; i < 2; i++ {
+		var elem [96]byte
+		for j := 0; j < 2; j++ {
+			e := new(big.Int).SetBytes(uniform[64*(2*i+j) : 64*(2*i+j+1)])
+			e.Mod(e, blsP)
+			// c0 goes second
+			e.FillBytes(elem[48*(1-j) : 48*(2-j)])
+		}
+		p, err := g2.MapToCurve(elem[:])
+		if err != nil {
+			return nil, err
+		}
+		g2.Add(ret, ret, p)
+	}
+	return g2.Affine(ret), nil
+}
+
+// RFC 9380 expand_message_xmd with SHA-256
+func expandMessageXmd(msg, dst []byte, outLen int) []byte {
+	dstPrime := append(append([]byte{}, dst...), byte(len(dst)))
+
+	h := sha256.New()
+	h.Write(make([]byte, 64))
+	h.Write(msg)
+	h.Write([]byte{byte(outLen >> 8), byte(outLen), 0})
+	h.Write(dstPrime)
+	b0 := h.Sum(nil)
+
+	var ret, prev []byte
+	for i := 1; len(ret) < outLen; i++ {
+		in := make([]byte, 32)
+		for k := range in {
+			in[k] = b0[k]
+			if prev != nil {
+				in[k] ^= prev[k]
+			}
+		}
+		h.Reset()
+		h.Write(in)
+		h.Write([]byte{byte(i)})
+		h.Write(dstPrime)
+		prev = h.Sum(nil)
+		ret = append(ret, prev...)
+	}
+	return ret[:outLen]
+}
+
+// Square root mod p, or nil if none. p = 3 mod 4.
+func fpSqrt(a *big.Int) *big.Int {
+	s := new(big.Int).Exp(a, blsSqrtE, blsP)
+	if new(big.Int).Exp(s, big.NewInt(2), blsP).Cmp(new(big.Int).Mod(a, blsP)) != 0 {
+		return nil
+	}
+	return s
+}
+
+// Element c0 + c1*u of Fp2 = Fp[u]/(u^2+1). Only used for decompression, so
+// simple beats fast.
+type fp2 struct {
+	c0, c1 *big.Int
+}
+
+func (a fp2) add(b fp2) fp2 {
+	return fp2{modP(new(big.Int).Add(a.c0, b.c0)), modP(new(big.Int).Add(a.c1, b.c1))}
+}
+
+func (a fp2) mul(b fp2) fp2 {
+	c0 := new(big.Int).Sub(new(big.Int).Mul(a.c0, b.c0), new(big.Int).Mul(a.c1, b.c1))
+	c1 := new(big.Int).Add(new(big.Int).Mul(a.c0, b.c1), new(big.Int).Mul(a.c1, b.c0))
+	return fp2{modP(c0), modP(c1)}
+}
+
+func (a fp2) neg() fp2 {
+	return fp2{modP(new(big.Int).Neg(a.c0)), modP(new(big.Int).Neg(a.c1))}
+}
+
+func (a fp2) equal(b fp2) bool {
+	return a.c0.Cmp(b.c0) == 0 && a.c1.Cmp(b.c1) == 0
+}
+
+// Square root via the norm: with g = sqrt(c0^2 + c1^2), x0 = sqrt((c0 +- g)/2)
+// and x1 = c1 / 2x0.
+func (a fp2) sqrt() (fp2, bool) {
+	var x fp2
+	if a.c1.Sign() == 0 {
+		if r := fpSqrt(a.c0); r != nil {
+			x = fp2{r, new(big.Int)}
+		} else if r := fpSqrt(modP(new(big.Int).Neg(a.c0))); r != nil {
+			x = fp2{new(big.Int), r}
+		} else {
+			return fp2{}, false
+		}
+	} else {
+		norm := modP(new(big.Int).Add(new(big.Int).Mul(a.c0, a.c0), new(big.Int).Mul(a.c1, a.c1)))
+		g := fpSqrt(norm)
+		if g == nil {
+			return fp2{}, false
+		}
+		d := modP(new(big.Int).Mul(new(big.Int).Add(a.c0, g), blsInv2))
+		x0 := fpSqrt(d)
+		if x0 == nil {
+			d = modP(new(big.Int).Mul(new(big.Int).Sub(a.c0, g), blsInv2))
+			if x0 = fpSqrt(d); x0 == nil {
+				return fp2{}, false
+			}
+		}
+		inv := new(big.Int).ModInverse(new(big.Int).Lsh(x0, 1), blsP)
+		if inv == nil {
+			return fp2{}, false
+		}
+		x = fp2{x0, modP(new(big.Int).Mul(a.c1, inv))}
+	}
+	if !x.mul(x).equal(a) {
+		return fp2{}, false
+	}
+	return x, true
+}
+
+// Sign convention for compressed points: compare c1, or c0 if c1 is zero.
+func (a fp2) lexLarger() bool {
+	if a.c1.Sign() != 0 {
+		return a.c1.Cmp(blsHalfP) > 0
+	}
+	return a.c0.Cmp(blsHalfP) > 0
+}
+
+func (a fp2) fillBytes(out []byte) {
+	a.c1.FillBytes(out[:48])
+	a.c0.FillBytes(out[48:96])
+}
+
+func modP(a *big.Int) *big.Int {
+	return a.Mod(a, blsP)
+}
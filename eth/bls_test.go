@@ -0,0 +1,151 @@
+package eth
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto/bls12381"
+)
+
+// RFC 9380 appendix K.1, expand_message_xmd with SHA-256
+func TestExpandMessageXmd(t *testing.T) {
+	dst := []byte("QUUX-V01-CS02-with-expander-SHA256-128")
+	tests := []struct {
+		msg    string
+		outLen int
+		want   string
+	}{
+		{"", 0x20, "68a985b87eb6b46952128911f2a4412bbc302a9d759667f87f7a21d803f07235"},
+		{"abc", 0x20, "d8ccab23b5985ccea865c6c97b6e5b8350e794e603b4b97902f53a8a0d605615"},
+		{"abcdef0123456789", 0x20, "eff31487c770a893cfb36f912fbfcbff40d5661771ca4b2cb4eafe524333f5c1"},
+		{"q128_" + strings.Repeat("q", 128), 0x20, "b23a1d2b4d97b2ef7785562a7e8bac7eed54ed6e97e29aa51bfe3f12ddad1ff9"},
+		{"a512_" + strings.Repeat("a", 512), 0x20, "4623227bcc01293b8c130bf771da8c298dede7383243dc0993d2d94823958c4c"},
+		{"", 0x80, "af84c27ccfd45d41914fdff5df25293e221afc53d8ad2ac06d5e3e29485dadbee0d121587713a3e0dd4d5e69e93eb7cd4f5df4cd103e188cf60cb02edc3edf18eda8576c412b18ffb658e3dd6ec849469b979d444cf7b26911a08e63cf31f9dcc541708d3491184472c2c29bb749d4286b004ceb5ee6b9a7fa5b646c993f0ced"},
+		{"abc", 0x80, "abba86a6129e366fc877aab32fc4ffc70120d8996c88aee2fe4b32d6c7b6437a647e6c3163d40b76a73cf6a5674ef1d890f95b664ee0afa5359a5c4e07985635bbecbac65d747d3d2da7ec2b8221b17b0ca9dc8a1ac1c07ea6a1e60583e2cb00058e77b7b72a298425cd1b941ad4ec65e8afc50303a22c0f99b0509b4c895f40"},
+	}
+	for _, tt := range tests {
+		got := hex.EncodeToString(expandMessageXmd([]byte(tt.msg), dst, tt.outLen))
+		if got != tt.want {
+			t.Errorf("%.10q len %d: got %s, want %s", tt.msg, tt.outLen, got, tt.want)
+		}
+	}
+}
+
+// RFC 9380 appendix J.10.1, BLS12381G2_XMD:SHA-256_SSWU_RO_. Coordinates
+// are c0, c1.
+func TestHashToG2(t *testing.T) {
+	dst := []byte("QUUX-V01-CS02-with-BLS12381G2_XMD:SHA-256_SSWU_RO_")
+	tests := []struct {
+		msg    string
+		x0, x1 string
+		y0, y1 string
+	}{
+		{
+			"",
+			"0141ebfbdca40eb85b87142e130ab689c673cf60f1a3e98d69335266f30d9b8d4ac44c1038e9dcdd5393faf5c41fb78a",
+			"05cb8437535e20ecffaef7752baddf98034139c38452458baeefab379ba13dff5bf5dd71b72418717047f5b0f37da03d",
+			"0503921d7f6a12805e72940b963c0cf3471c7b2a524950ca195d11062ee75ec076daf2d4bc358c4b190c0c98064fdd92",
+			"12424ac32561493f3fe3c260708a12b7c620e7be00099a974e259ddc7d1f6395c3c811cdd19f1e8dbf3e9ecfdcbab8d6",
+		},
+		{
+			"abc",
+			"02c2d18e033b960562aae3cab37a27ce00d80ccd5ba4b7fe0e7a210245129dbec7780ccc7954725f4168aff2787776e6",
+			"139cddbccdc5e91b9623efd38c49f81a6f83f175e80b06fc374de9eb4b41dfe4ca3a230ed250fbe3a2acf73a41177fd8",
+			"1787327b68159716a37440985269cf584bcb1e621d3a7202be6ea05c4cfe244aeb197642555a0645fb87bf7466b2ba48",
+			"00aa65dae3c8d732d10ecd2c50f8a1baf3001578f71c694e03866e9f3d49ac1e1ce70dd94a733534f106d4cec0eddd16",
+		},
+		{
+			"abcdef0123456789",
+			"121982811d2491fde9ba7ed31ef9ca474f0e1501297f68c298e9f4c0028add35aea8bb83d53c08cfc007c1e005723cd0",
+			"190d119345b94fbd15497bcba94ecf7db2cbfd1e1fe7da034d26cbba169fb3968288b3fafb265f9ebd380512a71c3f2c",
+			"05571a0f8d3c08d094576981f4a3b8eda0a8e771fcdcc8ecceaf1356a6acf17574518acb506e435b639353c2e14827c8",
+			"0bb5e7572275c567462d91807de765611490205a941a5a6af3b1691bfe596c31225d3aabdf15faff860cb4ef17c7c3be",
+		},
+		{
+			"q128_" + strings.Repeat("q", 128),
+			"19a84dd7248a1066f737cc34502ee5555bd3c19f2ecdb3c7d9e24dc65d4e25e50d83f0f77105e955d78f4762d33c17da",
+			"0934aba516a52d8ae479939a91998299c76d39cc0c035cd18813bec433f587e2d7a4fef038260eef0cef4d02aae3eb91",
+			"14f81cd421617428bc3b9fe25afbb751d934a00493524bc4e065635b0555084dd54679df1536101b2c979c0152d09192",
+			"09bcccfa036b4847c9950780733633f13619994394c23ff0b32fa6b795844f4a0673e20282d07bc69641cee04f5e5662",
+		},
+	}
+	g2 := bls12381.NewG2()
+	for _, tt := range tests {
+		p, err := blsHashToG2([]byte(tt.msg), dst)
+		if err != nil {
+			t.Fatalf("%.10q: %s", tt.msg, err)
+		}
+		// Uncompressed encoding puts c1 first
+		want := common.FromHex(tt.x1 + tt.x0 + tt.y1 + tt.y0)
+		if got := g2.ToBytes(p); !bytes.Equal(got, want) {
+			t.Errorf("%.10q: got %x, want %x", tt.msg, got, want)
+		}
+	}
+}
+
+// Compressed generators, per the ZCash serialization format
+const (
+	g1Compressed = "97f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb"
+	g2Compressed = "93e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb8"
+)
+
+func TestBlsDecodeG1(t *testing.T) {
+	g1 := bls12381.NewG1()
+	in := common.FromHex(g1Compressed)
+	p, err := blsDecodeG1(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g1.Equal(p, g1.One()) {
+		t.Fatal("generator decoded to a different point")
+	}
+
+	// Flipping the sign bit gives the negation
+	in[0] ^= 0x20
+	p, err = blsDecodeG1(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g1.Equal(p, g1.Neg(g1.New(), g1.One())) {
+		t.Fatal("sign flip did not negate")
+	}
+
+	for name, in := range map[string]string{
+		"infinity":     "c0" + strings.Repeat("00", 47),
+		"uncompressed": "17f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb",
+		"not on curve": "80" + strings.Repeat("00", 46) + "05",
+		"short":        g1Compressed[:94],
+	} {
+		if _, err := blsDecodeG1(common.FromHex(in)); err == nil {
+			t.Errorf("%s: decoded without error", name)
+		}
+	}
+}
+
+func TestBlsDecodeG2(t *testing.T) {
+	g2 := bls12381.NewG2()
+	in := common.FromHex(g2Compressed)
+	p, err := blsDecodeG2(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g2.Equal(p, g2.One()) {
+		t.Fatal("generator decoded to a different point")
+	}
+
+	in[0] ^= 0x20
+	p, err = blsDecodeG2(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g2.Equal(p, g2.Neg(g2.New(), g2.One())) {
+		t.Fatal("sign flip did not negate")
+	}
+
+	if _, err := blsDecodeG2(common.FromHex("c0" + strings.Repeat("00", 95))); err == nil {
+		t.Error("infinity decoded without error")
+	}
+}
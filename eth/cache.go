@@ -0,0 +1,105 @@
+package eth
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Cache bounds and lifetimes
+const (
+	cacheMaxItems = 1024
+	ttlChainID    = time.Minute
+	ttlEns        = 5 * time.Minute
+)
+
+// Bounded LRU cache. Each entry expires after a TTL, or once a new block
+// arrives if it was computed at a given block. Safe for concurrent use.
+type lruCache struct {
+	mu       sync.Mutex
+	maxItems int
+	head     uint64
+	order    *list.List // most recently used first
+	items    map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key string
+	val interface{}
+	// zero for no expiry
+	expires time.Time
+	// zero if not tied to a block
+	block uint64
+}
+
+func newLruCache(maxItems int) *lruCache {
+	return &lruCache{
+		maxItems: maxItems,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+func (c *lruCache) get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*cacheEntry)
+	if (!e.expires.IsZero() && time.Now().After(e.expires)) || (e.block != 0 && e.block != c.head) {
+		c.remove(el)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return e.val, true
+}
+
+// Stores a value. A zero ttl never expires; a nonzero block is only valid
+// until the next block.
+func (c *lruCache) put(key string, val interface{}, ttl time.Duration, block uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := &cacheEntry{key: key, val: val, block: block}
+	if ttl > 0 {
+		e.expires = time.Now().Add(ttl)
+	}
+	if el, ok := c.items[key]; ok {
+		el.Value = e
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.order.PushFront(e)
+	for c.order.Len() > c.maxItems {
+		c.remove(c.order.Back())
+	}
+}
+
+// Records a new chain head, dropping entries from earlier blocks.
+func (c *lruCache) setHead(block uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.head = block
+	for el := c.order.Front(); el != nil; {
+		next := el.Next()
+		if e := el.Value.(*cacheEntry); e.block != 0 && e.block != block {
+			c.remove(el)
+		}
+		el = next
+	}
+}
+
+func (c *lruCache) getHead() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.head
+}
+
+func (c *lruCache) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*cacheEntry).key)
+}
@@ -1,7 +1,7 @@
 package eth
 
 import (
-	"crypto/ecdsa"
+	"encoding/json"
 	"fmt"
 	"log"
 	"math/big"
@@ -12,65 +12,176 @@ import (
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
 	ens "github.com/wealdtech/go-ens/v3"
 	"golang.org/x/net/context"
 )
 
-// A caching Ethereum client. Forwards requests to a JSON RPC client.
+// A caching Ethereum client. Forwards requests to a JSON RPC client. Caches
+// ENS lookups, chain ID and token metadata for a while, and render results
+// until the next block.
 type Client struct {
-	Ec             *ethclient.Client
+	Ec *ethclient.Client
+	// Raw JSON RPC, for methods ethclient lacks
+	Rpc            *rpc.Client
 	LastConnStatus ConnStatus
+	// Attach an EIP-2930 access list from eth_createAccessList to transactions
+	UseAccessList bool
+	// Run frontend calls in a local EVM against proven state, see VerifiedCall
+	Verify bool
+	// Trusted headers for Verify. Defaults to trusting the RPC; see LightClient.
+	Headers HeaderSource
+	url     string
+	cache   *lruCache
+	// 1 while a newHeads subscription is live. Set by the SubscribeHeads
+	// goroutine, so accessed atomically.
+	headsLive int32
 }
 
 func CreateClient(ethRpcUrl string) *Client {
-	ec, err := ethclient.Dial(ethRpcUrl)
+	rpcClient, err := rpc.Dial(ethRpcUrl)
 	util.Must(err)
 
+	ec := ethclient.NewClient(rpcClient)
 	return &Client{
-		Ec: ec,
+		Ec:      ec,
+		Rpc:     rpcClient,
+		Headers: &rpcHeaderSource{ec},
+		url:     ethRpcUrl,
+		cache:   newLruCache(cacheMaxItems),
 	}
 }
 
+// Records a new chain head. Call on each new block, so that render results
+// from earlier blocks are dropped.
+func (c *Client) SetHead(block uint64) {
+	c.cache.setHead(block)
+}
+
+// Returns chain ID and name. Always asks the node, so this doubles as a
+// liveness check, and refreshes the cached chain ID.
 func (c *Client) ConnStatus() ConnStatus {
-	ctx, _ := context.WithTimeout(context.Background(), time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
 	cid, err := c.Ec.ChainID(ctx)
 	if err != nil {
-		c.LastConnStatus = ConnStatus{0, "", err.Error()}
+		c.LastConnStatus = ConnStatus{ErrorText: err.Error()}
 	} else {
+		c.cache.put("chainid", cid, ttlChainID, 0)
 		name := params.NetworkNames[cid.String()]
 		if name == "" {
 			name = fmt.Sprintf("CHAIN ID %d", cid)
 		}
-		c.LastConnStatus = ConnStatus{cid.Int64(), name, ""}
+		c.LastConnStatus = ConnStatus{ChainID: cid.Int64(), ChainName: name}
+		if c.Verify {
+			block, hash, err := c.Headers.TrustedHash()
+			if err == nil {
+				c.LastConnStatus.Verified = true
+				c.LastConnStatus.VerifiedBlock = block
+				c.LastConnStatus.VerifiedHash = hash
+			}
+		}
 	}
 	return c.LastConnStatus
 }
 
+func (c *Client) chainID() (*big.Int, error) {
+	if v, ok := c.cache.get("chainid"); ok {
+		return v.(*big.Int), nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	cid, err := c.Ec.ChainID(ctx)
+	if err == nil {
+		c.cache.put("chainid", cid, ttlChainID, 0)
+	}
+	return cid, err
+}
+
 type ConnStatus struct {
 	ChainID   int64
 	ChainName string
 	ErrorText string
+	// Frontend calls run against light client verified headers
+	Verified bool
+	// Latest verified block, if Verified
+	VerifiedBlock uint64
+	VerifiedHash  common.Hash
 }
 
 func (c *Client) Resolve(ensName string) (addr common.Address, err error) {
+	key := "ens:" + ensName
+	if v, ok := c.cache.get(key); ok {
+		return v.(common.Address), nil
+	}
 	addr, err = ens.Resolve(c.Ec, ensName)
+	if err == nil {
+		c.cache.put(key, addr, ttlEns, 0)
+	}
 	return
 }
 
-const abiIFrontendJson = `[{"inputs":[{"internalType":"bytes","name":"appState","type":"bytes"},{"components":[{"internalType":"uint256","name":"buttonKey","type":"uint256"},{"internalType":"bytes[]","name":"inputs","type":"bytes[]"}],"internalType":"struct Action","name":"action","type":"tuple"}],"name":"act","outputs":[{"internalType":"bytes","name":"newAppState","type":"bytes"}],"stateMutability":"nonpayable","type":"function"},{"inputs":[{"internalType":"bytes","name":"appState","type":"bytes"}],"name":"render","outputs":[{"components":[{"internalType":"uint64","name":"typeHash","type":"uint64"},{"internalType":"bytes","name":"data","type":"bytes"}],"internalType":"struct VElem[]","name":"vdom","type":"tuple[]"}],"stateMutability":"view","type":"function"}]`
+// Looks up the primary ENS name of an address via addr.reverse. Anyone can
+// set any reverse record, so the name only counts if it resolves back to the
+// same address; otherwise Err flags the mismatch. Returns just the address if
+// there's no reverse record.
+func (c *Client) ReverseResolve(addr common.Address) NamedAddr {
+	key := "reverse:" + addr.Hex()
+	if v, ok := c.cache.get(key); ok {
+		return v.(NamedAddr)
+	}
+	ret := c.reverseResolve(addr)
+	c.cache.put(key, ret, ttlEns, 0)
+	return ret
+}
+
+func (c *Client) reverseResolve(addr common.Address) NamedAddr {
+	ret := NamedAddr{Addr: addr}
+	name, err := ens.ReverseResolve(c.Ec, addr)
+	if err != nil || name == "" {
+		return ret
+	}
+	ret.Name = name
+	fwd, err := c.Resolve(name)
+	if err != nil {
+		ret.Err = fmt.Sprintf("reverse name %s does not resolve: %s", name, err)
+	} else if fwd != addr {
+		ret.Err = fmt.Sprintf("reverse name %s resolves to %s instead", name, fwd)
+	}
+	return ret
+}
+
+const abiIFrontendJson = `[{"inputs":[{"internalType":"bytes","name":"appState","type":"bytes"},{"components":[{"internalType":"uint256","name":"buttonKey","type":"uint256"},{"internalType":"bytes[]","name":"inputs","type":"bytes[]"}],"internalType":"struct Action","name":"action","type":"tuple"}],"name":"act","outputs":[{"internalType":"bytes","name":"newAppState","type":"bytes"},{"components":[{"internalType":"address","name":"target","type":"address"},{"internalType":"uint256","name":"value","type":"uint256"},{"internalType":"bytes","name":"data","type":"bytes"}],"internalType":"struct Call[]","name":"calls","type":"tuple[]"}],"stateMutability":"nonpayable","type":"function"},{"inputs":[{"internalType":"bytes","name":"appState","type":"bytes"}],"name":"render","outputs":[{"components":[{"internalType":"uint64","name":"typeHash","type":"uint64"},{"internalType":"bytes","name":"data","type":"bytes"}],"internalType":"struct VElem[]","name":"vdom","type":"tuple[]"}],"stateMutability":"view","type":"function"}]`
 
 var abiIFrontend = parseAbi(abiIFrontendJson)
 
 func parseAbi(json string) *abi.ABI {
-	abiObj, err := abi.JSON(strings.NewReader(abiIFrontendJson))
+	abiObj, err := abi.JSON(strings.NewReader(json))
 	util.Must(err)
 	return &abiObj
 }
 
+// Calls render() on a frontend contract. Results are cached until the next
+// block, eg for switching tabs or going back.
 func (c *Client) FrontendRender(fromAddr, contractAddr common.Address, appState []byte) (vdom []VElem, err error) {
+	head := c.cache.getHead()
+	// Keyed by block too, so a render from before a head change is never served after it
+	key := fmt.Sprintf("render:%d:%s:%s:%x", head, contractAddr, fromAddr, appState)
+	if v, ok := c.cache.get(key); ok {
+		return append([]VElem(nil), v.([]VElem)...), nil
+	}
+	vdom, err = c.frontendRender(fromAddr, contractAddr, appState)
+	if err == nil && head != 0 {
+		c.cache.put(key, append([]VElem(nil), vdom...), 0, head)
+	}
+	return vdom, err
+}
+
+func (c *Client) frontendRender(fromAddr, contractAddr common.Address, appState []byte) (vdom []VElem, err error) {
 	data, err := abiIFrontend.Pack("render", appState)
 	if err != nil {
 		return nil, err
@@ -82,9 +193,9 @@ func (c *Client) FrontendRender(fromAddr, contractAddr common.Address, appState
 		To:   &contractAddr,
 		Data: data,
 	}
-	vdomBytes, err := c.Ec.CallContract(context.Background(), callMsg, nil)
+	vdomBytes, err := c.frontendCall(callMsg)
 	if err != nil {
-		return nil, err
+		return nil, RevertError(err)
 	}
 
 	err = abiIFrontend.UnpackIntoInterface(&vdom, "render", vdomBytes)
@@ -102,7 +213,18 @@ func (c *Client) FrontendRender(fromAddr, contractAddr common.Address, appState
 	return
 }
 
-func (c *Client) FrontendSubmit(fromAddr, contractAddr common.Address, appState []byte, action ButtonAction) (msg *ethereum.CallMsg, err error) {
+// Calls a frontend contract, either via eth_call or verified locally.
+func (c *Client) frontendCall(msg ethereum.CallMsg) ([]byte, error) {
+	ctx := context.Background()
+	if c.Verify {
+		return c.VerifiedCall(ctx, msg)
+	}
+	return c.Ec.CallContract(ctx, msg, nil)
+}
+
+// Simulates act() on a frontend contract. Returns the new app state, plus
+// zero or more calls that the frontend proposes for the user to sign.
+func (c *Client) FrontendSubmit(fromAddr, contractAddr common.Address, appState []byte, action ButtonAction) (calls []ethereum.CallMsg, newAppState []byte, err error) {
 	abiAction := struct {
 		ButtonKey *big.Int
 		Inputs    [][]byte
@@ -112,7 +234,7 @@ func (c *Client) FrontendSubmit(fromAddr, contractAddr common.Address, appState
 	}
 	data, err := abiIFrontend.Pack("act", appState, abiAction)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	log.Printf("eth FrontendSubmit %s", contractAddr)
@@ -121,60 +243,165 @@ func (c *Client) FrontendSubmit(fromAddr, contractAddr common.Address, appState
 		To:   &contractAddr,
 		Data: data,
 	}
-	_, err = c.Ec.CallContract(context.Background(), callMsg, nil)
+	retBytes, err := c.frontendCall(callMsg)
+	if err != nil {
+		return nil, nil, RevertError(err)
+	}
 
-	return &callMsg, err
+	var ret struct {
+		NewAppState []byte
+		Calls       []struct {
+			Target common.Address
+			Value  *big.Int
+			Data   []byte
+		}
+	}
+	err = abiIFrontend.UnpackIntoInterface(&ret, "act", retBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, call := range ret.Calls {
+		target := call.Target
+		calls = append(calls, ethereum.CallMsg{
+			From:  fromAddr,
+			To:    &target,
+			Value: call.Value,
+			Data:  call.Data,
+		})
+	}
+	log.Printf("eth FrontendSubmit %s returned %d calls", contractAddr, len(calls))
+
+	return calls, ret.NewAppState, nil
 }
 
-func (c *Client) Execute(msg *ethereum.CallMsg, prv *ecdsa.PrivateKey) (*types.Transaction, error) {
+// Populates nonce, gas limit and fees for a call. Returns an unsigned
+// transaction, ready to be reviewed by the user and passed to Execute.
+// Uses the pending nonce, but never less than minNonce. That covers the next
+// transaction in a batch when the RPC node has not caught up yet.
+//
+// Uses msg.Gas if set. Otherwise estimates gas, unless estimate is false, eg
+// when the call depends on an earlier transaction that hasn't landed. Then
+// the gas limit is left zero for the caller to fill in, via WithGas.
+func (c *Client) PrepareTx(msg *ethereum.CallMsg, chainID *big.Int, minNonce uint64, fee Fee, estimate bool) (*types.Transaction, error) {
 	ctx := context.Background()
 
 	nonce, err := c.Ec.PendingNonceAt(ctx, msg.From)
 	if err != nil {
 		return nil, fmt.Errorf("nonce %s", err)
 	}
-	gasPrice, err := c.Ec.SuggestGasPrice(ctx)
+	if nonce < minNonce {
+		nonce = minNonce
+	}
+	// Access lists are simulated too, so skipped along with estimation
+	var accessList types.AccessList
+	if c.UseAccessList && estimate {
+		accessList, err = c.CreateAccessList(ctx, msg)
+		if err != nil {
+			return nil, fmt.Errorf("access list %s", err)
+		}
+	}
+	gas := msg.Gas
+	if gas == 0 && estimate {
+		gas, err = c.estimateGas(ctx, msg, accessList)
+		if err != nil {
+			return nil, fmt.Errorf("gas %s", err)
+		}
+	}
+
+	value := msg.Value
+	if value == nil {
+		value = big.NewInt(0)
+	}
+
+	return newTx(chainID, nonce, gas, msg.To, value, msg.Data, accessList, fee), nil
+}
+
+// Generates an EIP-2930 access list via eth_createAccessList.
+func (c *Client) CreateAccessList(ctx context.Context, msg *ethereum.CallMsg) (types.AccessList, error) {
+	var res struct {
+		AccessList types.AccessList `json:"accessList"`
+		Error      string           `json:"error"`
+	}
+	err := c.Rpc.CallContext(ctx, &res, "eth_createAccessList", toCallArg(msg, nil), "pending")
 	if err != nil {
-		return nil, fmt.Errorf("price %s", err)
+		return nil, err
+	}
+	if res.Error != "" {
+		return nil, fmt.Errorf(res.Error)
 	}
-	gas, err := c.Ec.EstimateGas(ctx, *msg)
+	return res.AccessList, nil
+}
+
+// Like ethclient EstimateGas, but also passes the access list, which
+// changes the gas used.
+func (c *Client) estimateGas(ctx context.Context, msg *ethereum.CallMsg, accessList types.AccessList) (uint64, error) {
+	if len(accessList) == 0 {
+		gas, err := c.Ec.EstimateGas(ctx, *msg)
+		return gas, RevertError(err)
+	}
+	var gas hexutil.Uint64
+	err := c.Rpc.CallContext(ctx, &gas, "eth_estimateGas", toCallArg(msg, accessList))
+	return uint64(gas), RevertError(err)
+}
+
+func toCallArg(msg *ethereum.CallMsg, accessList types.AccessList) map[string]interface{} {
+	arg := map[string]interface{}{
+		"from": msg.From,
+		"to":   msg.To,
+	}
+	if len(msg.Data) > 0 {
+		arg["data"] = hexutil.Bytes(msg.Data)
+	}
+	if msg.Value != nil {
+		arg["value"] = (*hexutil.Big)(msg.Value)
+	}
+	if accessList != nil {
+		arg["accessList"] = accessList
+	}
+	return arg
+}
+
+// Like ethclient TransactionReceipt, but also returns the effective gas price
+// paid, which the go-ethereum Receipt lacks. Returns ethereum.NotFound if the
+// transaction is not mined yet.
+func (c *Client) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, *big.Int, error) {
+	var raw json.RawMessage
+	err := c.Rpc.CallContext(ctx, &raw, "eth_getTransactionReceipt", hash)
 	if err != nil {
-		return nil, fmt.Errorf("gas %s", err)
-	}
-
-	// Infura gives "method not suppported"
-	// gasTipCap, err := c.Ec.SuggestGasTipCap(ctx)
-	// if err != nil {
-	// 	return nil, fmt.Errorf("tip %s", err)
-	// }
-	gasTipCap := big.NewInt(2_000_000_000) // 2 gwei
-	if gasTipCap.Cmp(gasPrice) > 0 {
-		gasTipCap.SetInt64(0)
-	}
-
-	chainID := big.NewInt(c.LastConnStatus.ChainID)
-	tx := types.NewTx(&types.DynamicFeeTx{
-		ChainID:   chainID,
-		Nonce:     nonce,
-		GasFeeCap: gasPrice,
-		GasTipCap: gasTipCap,
-		Gas:       gas,
-		To:        msg.To,
-		Value:     msg.Value,
-		Data:      msg.Data,
-	})
+		return nil, nil, err
+	} else if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil, ethereum.NotFound
+	}
+
+	var receipt types.Receipt
+	if err := json.Unmarshal(raw, &receipt); err != nil {
+		return nil, nil, err
+	}
+	var extra struct {
+		EffectiveGasPrice *hexutil.Big `json:"effectiveGasPrice"`
+	}
+	if err := json.Unmarshal(raw, &extra); err != nil {
+		return nil, nil, err
+	}
+	return &receipt, (*big.Int)(extra.EffectiveGasPrice), nil
+}
+
+// Signs and sends a transaction from PrepareTx.
+func (c *Client) Execute(tx *types.Transaction, chainID *big.Int, signer Signer) (*types.Transaction, error) {
+	ctx := context.Background()
 
 	log.Printf("eth SIGNING TRANSACTION. chain %d nonce %d fee cap %s tip %s gas %d from %s to %s",
 		chainID,
-		nonce,
-		gasPrice,
-		gasTipCap,
-		gas,
-		msg.From,
-		msg.To,
+		tx.Nonce(),
+		tx.GasFeeCap(),
+		tx.GasTipCap(),
+		tx.Gas(),
+		signer.Address(),
+		tx.To(),
 	)
 
-	txS, err := types.SignTx(tx, types.NewLondonSigner(chainID), prv)
+	txS, err := signer.SignTx(tx, chainID)
 	if err != nil {
 		return nil, err
 	}
@@ -197,9 +424,13 @@ func unpackElem(v *VElem) error {
 	case TypeButton:
 		v.DataElem = &ElemButton{}
 		return ParseTuple(v.Data, PropsButton, v.DataElem)
+	case TypeLink:
+		v.DataElem = &ElemLink{}
+		return ParseTuple(v.Data, PropsLink, v.DataElem)
 	case TypeInTextbox:
+		v.DataElem = &ElemTextbox{}
+		return ParseTuple(v.Data, PropsTextbox, v.DataElem)
 	default:
 		return fmt.Errorf("unsupported elem %d", v.TypeHash)
 	}
-	return nil
 }
@@ -0,0 +1,100 @@
+package eth
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const abiERC20Json = `[{"inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"name":"transfer","outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable","type":"function"},{"inputs":[{"name":"spender","type":"address"},{"name":"amount","type":"uint256"}],"name":"approve","outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable","type":"function"},{"inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"name":"transferFrom","outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable","type":"function"},{"inputs":[],"name":"deposit","outputs":[],"stateMutability":"payable","type":"function"},{"inputs":[{"name":"wad","type":"uint256"}],"name":"withdraw","outputs":[],"stateMutability":"nonpayable","type":"function"}]`
+
+const abiUniswapRouterJson = `[{"inputs":[{"name":"amountIn","type":"uint256"},{"name":"amountOutMin","type":"uint256"},{"name":"path","type":"address[]"},{"name":"to","type":"address"},{"name":"deadline","type":"uint256"}],"name":"swapExactTokensForTokens","outputs":[{"name":"amounts","type":"uint256[]"}],"stateMutability":"nonpayable","type":"function"},{"inputs":[{"name":"amountOutMin","type":"uint256"},{"name":"path","type":"address[]"},{"name":"to","type":"address"},{"name":"deadline","type":"uint256"}],"name":"swapExactETHForTokens","outputs":[{"name":"amounts","type":"uint256[]"}],"stateMutability":"payable","type":"function"},{"inputs":[{"name":"amountIn","type":"uint256"},{"name":"amountOutMin","type":"uint256"},{"name":"path","type":"address[]"},{"name":"to","type":"address"},{"name":"deadline","type":"uint256"}],"name":"swapExactTokensForETH","outputs":[{"name":"amounts","type":"uint256[]"}],"stateMutability":"nonpayable","type":"function"}]`
+
+// ABIs used to decode calldata for display. Checked in order.
+var knownAbis = []*abi.ABI{
+	abiIFrontend,
+	parseAbi(abiERC20Json),
+	parseAbi(abiUniswapRouterJson),
+}
+
+// Decodes calldata into a human-readable call, eg "approve(spender=0x…, amount=1)".
+// Falls back to the raw 4-byte selector for unknown functions.
+func DecodeCalldata(data []byte) string {
+	if len(data) == 0 {
+		return "(none, plain transfer)"
+	}
+	if len(data) < 4 {
+		return hexutil.Encode(data)
+	}
+	for _, a := range knownAbis {
+		method, err := a.MethodById(data[:4])
+		if err != nil {
+			continue
+		}
+		vals, err := method.Inputs.Unpack(data[4:])
+		if err != nil {
+			continue
+		}
+		args := make([]string, len(vals))
+		for i, v := range vals {
+			args[i] = fmt.Sprintf("%s=%s", method.Inputs[i].Name, formatArg(v))
+		}
+		return fmt.Sprintf("%s(%s)", method.Name, strings.Join(args, ", "))
+	}
+	return fmt.Sprintf("unknown function %s, %d bytes", hexutil.Encode(data[:4]), len(data))
+}
+
+// Returns the name of the called function, eg "approve", or the selector if unknown.
+func DecodeFunctionName(data []byte) string {
+	if len(data) == 0 {
+		return "transfer ETH"
+	}
+	if len(data) < 4 {
+		return ""
+	}
+	for _, a := range knownAbis {
+		if method, err := a.MethodById(data[:4]); err == nil {
+			return method.Name
+		}
+	}
+	return hexutil.Encode(data[:4])
+}
+
+func formatArg(v interface{}) string {
+	switch a := v.(type) {
+	case []byte:
+		return hexutil.Encode(a)
+	case [][]byte:
+		strs := make([]string, len(a))
+		for i, b := range a {
+			strs[i] = hexutil.Encode(b)
+		}
+		return "[" + strings.Join(strs, " ") + "]"
+	case *big.Int:
+		return a.String()
+	case common.Address:
+		return a.Hex()
+	case []common.Address:
+		strs := make([]string, len(a))
+		for i, b := range a {
+			strs[i] = b.Hex()
+		}
+		return "[" + strings.Join(strs, " ") + "]"
+	}
+
+	// Tuples decode to anonymous structs
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Struct {
+		fields := make([]string, rv.NumField())
+		for i := range fields {
+			fields[i] = fmt.Sprintf("%s=%s", rv.Type().Field(i).Name, formatArg(rv.Field(i).Interface()))
+		}
+		return "{" + strings.Join(fields, ", ") + "}"
+	}
+	return fmt.Sprintf("%v", v)
+}
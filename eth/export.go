@@ -0,0 +1,121 @@
+package eth
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"dcposch.eth/cli/util"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Encodes an unsigned transaction as the RLP payload a signer hashes and
+// signs, eg for signing on an air-gapped machine. Typed transactions are
+// prefixed with their type byte. Legacy transactions use EIP-155.
+func UnsignedTxRLP(tx *types.Transaction, chainID *big.Int) ([]byte, error) {
+	var fields []interface{}
+	switch tx.Type() {
+	case types.LegacyTxType:
+		fields = []interface{}{
+			tx.Nonce(), tx.GasPrice(), tx.Gas(), tx.To(), tx.Value(), tx.Data(),
+			chainID, uint(0), uint(0),
+		}
+	case types.AccessListTxType:
+		fields = []interface{}{
+			chainID, tx.Nonce(), tx.GasPrice(), tx.Gas(), tx.To(), tx.Value(), tx.Data(),
+			tx.AccessList(),
+		}
+	case types.DynamicFeeTxType:
+		fields = []interface{}{
+			chainID, tx.Nonce(), tx.GasTipCap(), tx.GasFeeCap(), tx.Gas(), tx.To(), tx.Value(), tx.Data(),
+			tx.AccessList(),
+		}
+	default:
+		return nil, fmt.Errorf("unsupported tx type %d", tx.Type())
+	}
+
+	enc, err := rlp.EncodeToBytes(fields)
+	if err != nil {
+		return nil, err
+	}
+	if tx.Type() == types.LegacyTxType {
+		return enc, nil
+	}
+	return append([]byte{tx.Type()}, enc...), nil
+}
+
+// Unsigned transaction export file, for offline signing
+type UnsignedTxExport struct {
+	From    common.Address `json:"from"`
+	ChainID *hexutil.Big   `json:"chainId"`
+	// Signing payload. See UnsignedTxRLP.
+	Rlp hexutil.Bytes `json:"rlp"`
+	// Fully populated transaction fields
+	Tx *types.Transaction `json:"tx"`
+}
+
+func ExportUnsignedTx(tx *types.Transaction, chainID *big.Int, from common.Address) ([]byte, error) {
+	enc, err := UnsignedTxRLP(tx, chainID)
+	if err != nil {
+		return nil, err
+	}
+	export := UnsignedTxExport{
+		From:    from,
+		ChainID: (*hexutil.Big)(new(big.Int).Set(chainID)),
+		Rlp:     enc,
+		Tx:      tx,
+	}
+	return json.MarshalIndent(export, "", "  ")
+}
+
+// Decodes a signed transaction, either hex-encoded RLP or the binary
+// typed-transaction encoding, and recovers the sender.
+func DecodeSignedTx(raw []byte) (tx *types.Transaction, from common.Address, err error) {
+	str := strings.TrimSpace(string(raw))
+	if hex, err := hexutil.Decode(str); err == nil {
+		raw = hex
+	} else if hex, err := hexutil.Decode("0x" + str); err == nil {
+		raw = hex
+	}
+
+	tx = new(types.Transaction)
+	if err = tx.UnmarshalBinary(raw); err != nil {
+		return nil, from, fmt.Errorf("invalid transaction: %s", err)
+	}
+
+	var signer types.Signer = types.HomesteadSigner{}
+	if tx.Protected() {
+		signer = types.LatestSignerForChainID(tx.ChainId())
+	}
+	from, err = types.Sender(signer, tx)
+	if err != nil {
+		return nil, from, fmt.Errorf("invalid signature: %s", err)
+	}
+	return tx, from, nil
+}
+
+// Describes a signed transaction for review before broadcasting.
+func SignedTxSummary(tx *types.Transaction, from common.Address) []string {
+	to := "(contract creation)"
+	if tx.To() != nil {
+		to = tx.To().Hex()
+	}
+	chainID := "none (replayable on any chain)"
+	if tx.Protected() {
+		chainID = tx.ChainId().String()
+	}
+	return []string{
+		fmt.Sprintf("Hash: %s", tx.Hash()),
+		fmt.Sprintf("From: %s", from),
+		fmt.Sprintf("To: %s", to),
+		fmt.Sprintf("Value: %s ETH", util.ToFixedPrecision(tx.Value(), 18)),
+		fmt.Sprintf("Call: %s", DecodeCalldata(tx.Data())),
+		fmt.Sprintf("Nonce: %d", tx.Nonce()),
+		fmt.Sprintf("Gas: %d", tx.Gas()),
+		fmt.Sprintf("Chain ID: %s", chainID),
+	}
+}
@@ -0,0 +1,226 @@
+package eth
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// EIP-1559 fee cap and priority fee (tip), in wei
+type Fee struct {
+	MaxFee *big.Int
+	Tip    *big.Int
+	// Pre-London chain. MaxFee is the gas price, same as Tip.
+	Legacy bool
+}
+
+// Fee presets, from slow to fast
+type FeeOptions struct {
+	Slow   Fee
+	Normal Fee
+	Fast   Fee
+	// Base fee of the next block, for reference. Nil before London.
+	BaseFee *big.Int
+}
+
+const (
+	feeHistoryBlocks = 20
+	// Used when the node gives us nothing better.
+	fallbackTip = 2_000_000_000 // 2 gwei
+)
+
+// Tip percentiles for slow, normal and fast.
+var feePercentiles = []float64{10, 50, 90}
+
+// Estimates fees from eth_feeHistory. Falls back to eth_maxPriorityFeePerGas
+// plus the latest base fee, then to eth_gasPrice with a fixed 2 gwei tip,
+// since some providers lack one or both.
+func (c *Client) EstimateFees(ctx context.Context) (*FeeOptions, error) {
+	// Chains and devnets that never activated EIP-1559 have no base fee.
+	head, err := c.Ec.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("head %s", err)
+	}
+	if head.BaseFee == nil {
+		return c.legacyFees(ctx)
+	}
+
+	opts, err := c.feesFromHistory(ctx)
+	if err == nil {
+		return opts, nil
+	}
+	log.Printf("eth EstimateFees feeHistory failed, falling back: %s", err)
+
+	opts, err = c.feesFromTipCap(ctx)
+	if err == nil {
+		return opts, nil
+	}
+	log.Printf("eth EstimateFees maxPriorityFeePerGas failed, falling back: %s", err)
+
+	gasPrice, err := c.Ec.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("price %s", err)
+	}
+	tip := big.NewInt(fallbackTip)
+	if tip.Cmp(gasPrice) > 0 {
+		tip.SetInt64(0)
+	}
+	fee := Fee{MaxFee: gasPrice, Tip: tip}
+	return &FeeOptions{Slow: fee, Normal: fee, Fast: fee, BaseFee: new(big.Int).Sub(gasPrice, tip)}, nil
+}
+
+// Gas price presets for pre-London chains
+func (c *Client) legacyFees(ctx context.Context) (*FeeOptions, error) {
+	gasPrice, err := c.Ec.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("price %s", err)
+	}
+	scaled := func(pct int64) Fee {
+		p := new(big.Int).Mul(gasPrice, big.NewInt(pct))
+		p.Div(p, big.NewInt(100))
+		return Fee{MaxFee: p, Tip: p, Legacy: true}
+	}
+	return &FeeOptions{Slow: scaled(90), Normal: scaled(100), Fast: scaled(125)}, nil
+}
+
+func (c *Client) feesFromHistory(ctx context.Context) (*FeeOptions, error) {
+	var res struct {
+		BaseFee []*hexutil.Big   `json:"baseFeePerGas"`
+		Reward  [][]*hexutil.Big `json:"reward"`
+	}
+	err := c.Rpc.CallContext(ctx, &res, "eth_feeHistory",
+		hexutil.Uint(feeHistoryBlocks), "latest", feePercentiles)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.BaseFee) == 0 || len(res.Reward) == 0 {
+		return nil, fmt.Errorf("empty fee history")
+	}
+
+	// The last base fee is for the next, pending block.
+	baseFee := res.BaseFee[len(res.BaseFee)-1].ToInt()
+	tips := make([]*big.Int, len(feePercentiles))
+	for i := range feePercentiles {
+		var blockTips []*big.Int
+		for _, r := range res.Reward {
+			if i < len(r) {
+				blockTips = append(blockTips, r[i].ToInt())
+			}
+		}
+		tips[i] = median(blockTips)
+	}
+
+	return &FeeOptions{
+		Slow:    feeFor(baseFee, tips[0]),
+		Normal:  feeFor(baseFee, tips[1]),
+		Fast:    feeFor(baseFee, tips[2]),
+		BaseFee: baseFee,
+	}, nil
+}
+
+func (c *Client) feesFromTipCap(ctx context.Context) (*FeeOptions, error) {
+	tip, err := c.Ec.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	head, err := c.Ec.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if head.BaseFee == nil {
+		return nil, fmt.Errorf("no base fee, chain is pre-London")
+	}
+	slowTip := new(big.Int).Div(tip, big.NewInt(2))
+	fastTip := new(big.Int).Mul(tip, big.NewInt(2))
+	return &FeeOptions{
+		Slow:    feeFor(head.BaseFee, slowTip),
+		Normal:  feeFor(head.BaseFee, tip),
+		Fast:    feeFor(head.BaseFee, fastTip),
+		BaseFee: head.BaseFee,
+	}, nil
+}
+
+// Max fee leaves room for the base fee to double, ~6 full blocks.
+func feeFor(baseFee, tip *big.Int) Fee {
+	maxFee := new(big.Int).Mul(baseFee, big.NewInt(2))
+	maxFee.Add(maxFee, tip)
+	return Fee{MaxFee: maxFee, Tip: new(big.Int).Set(tip)}
+}
+
+func median(vals []*big.Int) *big.Int {
+	if len(vals) == 0 {
+		return big.NewInt(0)
+	}
+	sort.Slice(vals, func(i, j int) bool { return vals[i].Cmp(vals[j]) < 0 })
+	return new(big.Int).Set(vals[len(vals)/2])
+}
+
+// Returns a copy of an unsigned transaction with different fees.
+func WithFee(tx *types.Transaction, fee Fee) (*types.Transaction, error) {
+	if fee.Tip.Cmp(fee.MaxFee) > 0 {
+		return nil, fmt.Errorf("tip exceeds max fee")
+	}
+	return newTx(typedChainID(tx), tx.Nonce(), tx.Gas(), tx.To(), tx.Value(), tx.Data(), tx.AccessList(), fee), nil
+}
+
+// Returns a copy of an unsigned transaction with a new gas limit.
+func WithGas(tx *types.Transaction, gas uint64) *types.Transaction {
+	fee := Fee{MaxFee: tx.GasFeeCap(), Tip: tx.GasTipCap(), Legacy: tx.Type() != types.DynamicFeeTxType}
+	return newTx(typedChainID(tx), tx.Nonce(), gas, tx.To(), tx.Value(), tx.Data(), tx.AccessList(), fee)
+}
+
+// Chain ID of a typed transaction. Unsigned legacy transactions have none.
+func typedChainID(tx *types.Transaction) *big.Int {
+	if tx.Type() == types.LegacyTxType {
+		return nil
+	}
+	return tx.ChainId()
+}
+
+// Creates an unsigned transaction. Uses EIP-1559 unless the fee is legacy,
+// then an EIP-2930 access list transaction if there's an access list, or
+// else a legacy EIP-155 transaction.
+//
+// An unsigned legacy transaction has no chain ID field: it enters only the
+// signing payload, then the signature V. So V, R and S stay zero here, and
+// signing and export take the chain ID separately.
+func newTx(chainID *big.Int, nonce, gas uint64, to *common.Address, value *big.Int, data []byte, accessList types.AccessList, fee Fee) *types.Transaction {
+	if !fee.Legacy {
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:    chainID,
+			Nonce:      nonce,
+			GasFeeCap:  fee.MaxFee,
+			GasTipCap:  fee.Tip,
+			Gas:        gas,
+			To:         to,
+			Value:      value,
+			Data:       data,
+			AccessList: accessList,
+		})
+	} else if len(accessList) > 0 {
+		return types.NewTx(&types.AccessListTx{
+			ChainID:    chainID,
+			Nonce:      nonce,
+			GasPrice:   fee.MaxFee,
+			Gas:        gas,
+			To:         to,
+			Value:      value,
+			Data:       data,
+			AccessList: accessList,
+		})
+	}
+	return types.NewTx(&types.LegacyTx{
+		Nonce:    nonce,
+		GasPrice: fee.MaxFee,
+		Gas:      gas,
+		To:       to,
+		Value:    value,
+		Data:     data,
+	})
+}
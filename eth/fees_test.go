@@ -0,0 +1,66 @@
+package eth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Unsigned transactions of each type, as PrepareTx makes them
+func testTxs() map[string]*types.Transaction {
+	to := common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")
+	accessList := types.AccessList{{Address: to, StorageKeys: []common.Hash{common.HexToHash("0x01")}}}
+	chainID := big.NewInt(1)
+	legacyFee := Fee{MaxFee: big.NewInt(20e9), Tip: big.NewInt(20e9), Legacy: true}
+	return map[string]*types.Transaction{
+		"legacy":      newTx(nil, 7, 21000, &to, big.NewInt(1), nil, nil, legacyFee),
+		"access list": newTx(chainID, 7, 21000, &to, big.NewInt(1), nil, accessList, legacyFee),
+		"dynamic fee": newTx(chainID, 7, 21000, &to, big.NewInt(1), nil, accessList,
+			Fee{MaxFee: big.NewInt(30e9), Tip: big.NewInt(1e9)}),
+	}
+}
+
+func TestWithGasKeepsType(t *testing.T) {
+	for name, tx := range testTxs() {
+		got := WithGas(tx, 50000)
+		if got.Type() != tx.Type() {
+			t.Errorf("%s: type %d became %d", name, tx.Type(), got.Type())
+		}
+		if got.Gas() != 50000 || got.GasFeeCap().Cmp(tx.GasFeeCap()) != 0 || got.GasTipCap().Cmp(tx.GasTipCap()) != 0 {
+			t.Errorf("%s: got gas %d, fee cap %s, tip %s", name, got.Gas(), got.GasFeeCap(), got.GasTipCap())
+		}
+		if len(got.AccessList()) != len(tx.AccessList()) || got.Nonce() != tx.Nonce() {
+			t.Errorf("%s: fields not copied", name)
+		}
+	}
+}
+
+func TestWithFeeKeepsType(t *testing.T) {
+	for name, tx := range testTxs() {
+		legacy := tx.Type() != types.DynamicFeeTxType
+		fee := Fee{MaxFee: big.NewInt(40e9), Tip: big.NewInt(2e9), Legacy: legacy}
+		if legacy {
+			fee.Tip = fee.MaxFee
+		}
+		got, err := WithFee(tx, fee)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if got.Type() != tx.Type() {
+			t.Errorf("%s: type %d became %d", name, tx.Type(), got.Type())
+		}
+		if got.GasFeeCap().Cmp(fee.MaxFee) != 0 || got.GasTipCap().Cmp(fee.Tip) != 0 || got.Gas() != tx.Gas() {
+			t.Errorf("%s: got fee cap %s, tip %s, gas %d", name, got.GasFeeCap(), got.GasTipCap(), got.Gas())
+		}
+		if typedChainID(got) == nil != (tx.Type() == types.LegacyTxType) {
+			t.Errorf("%s: chain ID %v", name, typedChainID(got))
+		}
+	}
+
+	_, err := WithFee(testTxs()["dynamic fee"], Fee{MaxFee: big.NewInt(1e9), Tip: big.NewInt(2e9)})
+	if err == nil {
+		t.Error("accepted a tip over the max fee")
+	}
+}
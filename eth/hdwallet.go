@@ -0,0 +1,115 @@
+package eth
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip39"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Derives the first n accounts from a BIP-39 mnemonic, using the standard
+// Ethereum BIP-44 path m/44'/60'/0'/0/i.
+//
+// The mnemonic must use the English wordlist and have a valid checksum, so a
+// typo fails rather than yielding a different wallet.
+func DeriveHDKeys(mnemonic, passphrase string, n int) ([]*ecdsa.PrivateKey, error) {
+	words := strings.Fields(strings.ToLower(norm.NFKD.String(mnemonic)))
+	if err := checkMnemonicWords(words); err != nil {
+		return nil, err
+	}
+	seed, err := bip39.NewSeedWithErrorChecking(strings.Join(words, " "), norm.NFKD.String(passphrase))
+	if err == bip39.ErrChecksumIncorrect {
+		return nil, fmt.Errorf("invalid mnemonic checksum, check for typos")
+	} else if err != nil {
+		return nil, fmt.Errorf("invalid mnemonic: %s", err)
+	}
+
+	master, chainCode := hdMasterKey(seed)
+	ret := make([]*ecdsa.PrivateKey, n)
+	for i := range ret {
+		path := make(accounts.DerivationPath, len(accounts.DefaultBaseDerivationPath))
+		copy(path, accounts.DefaultBaseDerivationPath)
+		path[len(path)-1] = uint32(i)
+
+		key, err := hdDerive(master, chainCode, path)
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = key
+	}
+	return ret, nil
+}
+
+// Checks mnemonic length and words, for a more specific error than go-bip39
+// gives. It then checks the checksum.
+func checkMnemonicWords(words []string) error {
+	switch len(words) {
+	case 12, 15, 18, 21, 24:
+	default:
+		return fmt.Errorf("mnemonic has %d words, expected 12 to 24", len(words))
+	}
+	for i, w := range words {
+		if _, ok := bip39.GetWordIndex(w); !ok {
+			return fmt.Errorf("mnemonic word %d, %q, is not in the BIP-39 wordlist", i+1, w)
+		}
+	}
+	return nil
+}
+
+// BIP-32 master key and chain code
+func hdMasterKey(seed []byte) (*big.Int, []byte) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	return new(big.Int).SetBytes(sum[:32]), sum[32:]
+}
+
+// BIP-32 private child key derivation along a path
+func hdDerive(key *big.Int, chainCode []byte, path accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	n := crypto.S256().Params().N
+	for _, index := range path {
+		var data []byte
+		if index >= 0x80000000 {
+			// Hardened: 0x00 || private key || index
+			data = append([]byte{0}, padTo32(key)...)
+		} else {
+			// Normal: compressed public key || index
+			prv, err := crypto.ToECDSA(padTo32(key))
+			if err != nil {
+				return nil, err
+			}
+			data = crypto.CompressPubkey(&prv.PublicKey)
+		}
+		var ix [4]byte
+		binary.BigEndian.PutUint32(ix[:], index)
+		data = append(data, ix[:]...)
+
+		mac := hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		sum := mac.Sum(nil)
+
+		il := new(big.Int).SetBytes(sum[:32])
+		if il.Cmp(n) >= 0 {
+			return nil, fmt.Errorf("invalid child key at index %d", index)
+		}
+		key = il.Add(il, key).Mod(il, n)
+		if key.Sign() == 0 {
+			return nil, fmt.Errorf("invalid child key at index %d", index)
+		}
+		chainCode = sum[32:]
+	}
+	return crypto.ToECDSA(padTo32(key))
+}
+
+func padTo32(v *big.Int) []byte {
+	ret := make([]byte, 32)
+	return v.FillBytes(ret)
+}
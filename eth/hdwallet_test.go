@@ -0,0 +1,91 @@
+package eth
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestDeriveHDKeys(t *testing.T) {
+	keys, err := DeriveHDKeys(testMnemonic, "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94")
+	if got := crypto.PubkeyToAddress(keys[0].PublicKey); got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func TestDeriveHDKeysNormalizes(t *testing.T) {
+	// Precomposed and decomposed e-acute must give the same seed
+	a, err := DeriveHDKeys(testMnemonic, "caf\u00e9", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := DeriveHDKeys(testMnemonic, "cafe\u0301", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a[0].D.Cmp(b[0].D) != 0 {
+		t.Fatal("passphrase not NFKD normalized")
+	}
+}
+
+func TestDeriveHDKeysInvalid(t *testing.T) {
+	tests := map[string]string{
+		"abandon abandon abandon":                                                   "has 3 words",
+		strings.Repeat("abandon ", 12):                                              "checksum",
+		strings.Replace(testMnemonic, "about", "aboot", 1):                          "not in the BIP-39 wordlist",
+		"legal winner thank year wave sausage worth useful legal winner thank year": "checksum",
+	}
+	for mnemonic, want := range tests {
+		_, err := DeriveHDKeys(mnemonic, "", 1)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: got %v, want error containing %q", mnemonic, err, want)
+		}
+	}
+	if _, err := DeriveHDKeys("legal winner thank year wave sausage worth useful legal winner thank yellow", "", 1); err != nil {
+		t.Errorf("valid mnemonic rejected: %s", err)
+	}
+}
+
+// BIP-32 test vector 1
+func TestHDDerive(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master, chainCode := hdMasterKey(seed)
+	tests := []struct {
+		path string
+		key  string
+	}{
+		{"m", "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35"},
+		{"m/0'", "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea"},
+		{"m/0'/1", "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368"},
+		{"m/0'/1/2'", "cbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca"},
+		{"m/0'/1/2'/2", "0f479245fb19a38a1954c5c7c0ebab2f9bdfd96a17563ef28a6a4b1a2a764ef4"},
+		{"m/0'/1/2'/2/1000000000", "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8"},
+	}
+	for _, tt := range tests {
+		var path accounts.DerivationPath
+		if tt.path != "m" {
+			var err error
+			if path, err = accounts.ParseDerivationPath(tt.path); err != nil {
+				t.Fatal(err)
+			}
+		}
+		key, err := hdDerive(new(big.Int).Set(master), chainCode, path)
+		if err != nil {
+			t.Fatalf("%s: %s", tt.path, err)
+		}
+		if got := hex.EncodeToString(crypto.FromECDSA(key)); got != tt.key {
+			t.Errorf("%s: got %s, want %s", tt.path, got, tt.key)
+		}
+	}
+}
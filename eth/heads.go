@@ -0,0 +1,72 @@
+package eth
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// True if connected over ws:// or wss://, which supports subscriptions.
+func (c *Client) IsWebsocket() bool {
+	return strings.HasPrefix(c.url, "ws://") || strings.HasPrefix(c.url, "wss://")
+}
+
+// Streams new block headers via a newHeads subscription. Resubscribes after
+// a disconnect, with backoff; the RPC client reconnects on the next request.
+// If the reader falls behind, skips to the latest head.
+func (c *Client) SubscribeHeads() <-chan *types.Header {
+	out := make(chan *types.Header, 1)
+	go func() {
+		backoff := time.Second
+		for {
+			ok, err := c.followHeads(out)
+			if ok {
+				backoff = time.Second
+			}
+			log.Printf("eth newHeads subscription ended: %v. Retrying in %s", err, backoff)
+			time.Sleep(backoff)
+			if backoff < 30*time.Second {
+				backoff *= 2
+			}
+		}
+	}()
+	return out
+}
+
+// True while the SubscribeHeads subscription is live. While it's down, eg
+// reconnecting, new blocks only show up by polling.
+func (c *Client) HeadsLive() bool {
+	return atomic.LoadInt32(&c.headsLive) == 1
+}
+
+// Forwards headers until the subscription fails. Returns whether it
+// subscribed successfully, plus the error that ended it.
+func (c *Client) followHeads(out chan *types.Header) (bool, error) {
+	heads := make(chan *types.Header)
+	sub, err := c.Ec.SubscribeNewHead(context.Background(), heads)
+	if err != nil {
+		return false, err
+	}
+	defer sub.Unsubscribe()
+	log.Printf("eth subscribed to newHeads")
+	atomic.StoreInt32(&c.headsLive, 1)
+	defer atomic.StoreInt32(&c.headsLive, 0)
+
+	for {
+		select {
+		case h := <-heads:
+			// Only the latest head matters. Drop a stale one, if unread.
+			select {
+			case <-out:
+			default:
+			}
+			out <- h
+		case err := <-sub.Err():
+			return true, err
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package eth
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Loads an encrypted, geth-style V3 JSON keystore file. Path is either the
+// file itself or a keystore directory. In a directory, picks the file for the
+// given account address, or the only file if account is empty.
+func LoadKeystore(path string, account string) (keyJson []byte, addr common.Address, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, addr, err
+	}
+	if !info.IsDir() {
+		return readKeystoreFile(path)
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, addr, err
+	}
+	var matches [][]byte
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		js, a, err := readKeystoreFile(filepath.Join(path, e.Name()))
+		if err != nil {
+			continue // not a keystore file
+		}
+		if account == "" || a == common.HexToAddress(account) {
+			keyJson, addr = js, a
+			matches = append(matches, js)
+		}
+	}
+
+	if len(matches) == 0 {
+		return nil, addr, fmt.Errorf("no keystore file for account '%s' in %s", account, path)
+	} else if len(matches) > 1 {
+		return nil, addr, fmt.Errorf("%d keystore files in %s, pick one with --account", len(matches), path)
+	}
+	return keyJson, addr, nil
+}
+
+func readKeystoreFile(path string) (keyJson []byte, addr common.Address, err error) {
+	keyJson, err = os.ReadFile(path)
+	if err != nil {
+		return nil, addr, err
+	}
+	var header struct {
+		Address string          `json:"address"`
+		Crypto  json.RawMessage `json:"crypto"`
+		Version int             `json:"version"`
+	}
+	if err = json.Unmarshal(keyJson, &header); err != nil {
+		return nil, addr, err
+	}
+	if header.Version != 3 || !common.IsHexAddress(header.Address) {
+		return nil, addr, fmt.Errorf("not a V3 keystore file: %s", path)
+	}
+	return keyJson, common.HexToAddress(header.Address), nil
+}
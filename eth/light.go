@@ -0,0 +1,354 @@
+package eth
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto/bls12381"
+)
+
+// A light client head older than this no longer counts as verified
+const maxLightHeadAge = 2 * time.Minute
+
+// Follows the beacon chain via the light client sync protocol, from a
+// user-pinned checkpoint block root. Each sync committee is proven by the
+// one before it, back to the checkpoint, and each header is signed by a
+// supermajority of its committee. Serves the latest such header as a
+// HeaderSource, so that verified calls don't trust the RPC at all.
+type LightClient struct {
+	beaconUrl  string
+	checkpoint common.Hash
+	net        *beaconNetwork
+
+	// Written only by the sync goroutine, under mu
+	mu sync.Mutex
+	// Sync committee period of current
+	period  uint64
+	current *syncCommittee
+	// Committee for the next period, once known
+	next      *syncCommittee
+	finalized *lightHeader
+	// Latest signed header, usually a slot or two behind the chain
+	head *lightHeader
+}
+
+// Sync committee with decoded public keys
+type syncCommittee struct {
+	pubkeys []*bls12381.PointG1
+}
+
+// Creates a light client for the given execution chain. Call Start to sync.
+func NewLightClient(beaconUrl string, checkpoint common.Hash, chainID int64) (*LightClient, error) {
+	net := beaconNetworks[chainID]
+	if net == nil {
+		return nil, fmt.Errorf("light client does not support chain ID %d", chainID)
+	}
+	return &LightClient{
+		beaconUrl:  beaconUrl,
+		checkpoint: checkpoint,
+		net:        net,
+	}, nil
+}
+
+// Bootstraps from the checkpoint, then follows new headers, once per slot.
+func (lc *LightClient) Start() {
+	go func() {
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			if err := lc.sync(ctx); err != nil {
+				log.Printf("light client: %s", err)
+			}
+			cancel()
+			time.Sleep(slotSeconds * time.Second)
+		}
+	}()
+}
+
+// Returns the latest verified execution header. Fails until synced, or if
+// the beacon node has stopped serving updates.
+func (lc *LightClient) TrustedHead(ctx context.Context) (*types.Header, error) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	ex, err := lc.freshHead()
+	if err != nil {
+		return nil, err
+	}
+	return &types.Header{
+		ParentHash:  ex.ParentHash,
+		Coinbase:    ex.FeeRecipient,
+		Root:        ex.StateRoot,
+		TxHash:      ex.TransactionsRoot,
+		ReceiptHash: ex.ReceiptsRoot,
+		Bloom:       types.BytesToBloom(ex.LogsBloom),
+		Difficulty:  new(big.Int),
+		Number:      new(big.Int).SetUint64(uint64(ex.BlockNumber)),
+		GasLimit:    uint64(ex.GasLimit),
+		GasUsed:     uint64(ex.GasUsed),
+		Time:        uint64(ex.Timestamp),
+		Extra:       ex.ExtraData,
+		MixDigest:   ex.PrevRandao,
+		BaseFee:     new(big.Int).Set((*big.Int)(&ex.BaseFeePerGas)),
+	}, nil
+}
+
+// Returns the latest verified execution block number and hash. The hash
+// comes from the signed payload header, so unlike TrustedHead's, it's exact.
+func (lc *LightClient) TrustedHash() (uint64, common.Hash, error) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	ex, err := lc.freshHead()
+	if err != nil {
+		return 0, common.Hash{}, err
+	}
+	return uint64(ex.BlockNumber), ex.BlockHash, nil
+}
+
+// Latest execution header, unless not synced or stale. Call under mu.
+func (lc *LightClient) freshHead() (*executionHeader, error) {
+	if lc.head == nil {
+		return nil, fmt.Errorf("light client not synced yet")
+	}
+	ex := lc.head.Execution
+	if age := lc.headAge(); age > maxLightHeadAge {
+		return nil, fmt.Errorf("light client head #%d is stale, %s old", ex.BlockNumber, age.Round(time.Second))
+	}
+	return ex, nil
+}
+
+func (lc *LightClient) headAge() time.Duration {
+	return time.Since(time.Unix(int64(lc.head.Execution.Timestamp), 0))
+}
+
+// One round: bootstrap if needed, catch up on sync committee periods, then
+// apply the latest finality and optimistic updates.
+func (lc *LightClient) sync(ctx context.Context) error {
+	if lc.current == nil {
+		if err := lc.bootstrap(ctx); err != nil {
+			return err
+		}
+	}
+	if err := lc.syncPeriods(ctx); err != nil {
+		return err
+	}
+
+	for _, kind := range []string{"finality_update", "optimistic_update"} {
+		var resp struct{ Data lightUpdate }
+		if err := beaconGet(ctx, lc.beaconUrl, "/eth/v1/beacon/light_client/"+kind, &resp); err != nil {
+			return err
+		}
+		if err := lc.applyUpdate(&resp.Data); err != nil {
+			return fmt.Errorf("%s: %s", kind, err)
+		}
+	}
+	return nil
+}
+
+// Fetches the checkpoint header and the sync committee in its state.
+func (lc *LightClient) bootstrap(ctx context.Context) error {
+	var resp struct{ Data lightBootstrap }
+	if err := beaconGet(ctx, lc.beaconUrl, "/eth/v1/beacon/light_client/bootstrap/"+lc.checkpoint.Hex(), &resp); err != nil {
+		return err
+	}
+	b := &resp.Data
+	if root := b.Header.Beacon.hashTreeRoot(); root != lc.checkpoint {
+		return fmt.Errorf("bootstrap header %s does not match checkpoint %s", root, lc.checkpoint)
+	}
+	if err := lc.verifyHeader(&b.Header); err != nil {
+		return fmt.Errorf("bootstrap: %s", err)
+	}
+	slot := uint64(b.Header.Beacon.Slot)
+	if !sszVerifyBranch(b.CurrentSyncCommittee.hashTreeRoot(), b.CurrentSyncCommitteeBranch,
+		lc.net.stateDepth(slot), stateIndexCurrentCommittee, b.Header.Beacon.StateRoot) {
+		return fmt.Errorf("bootstrap: invalid sync committee proof")
+	}
+	committee, err := decodeSyncCommittee(&b.CurrentSyncCommittee)
+	if err != nil {
+		return fmt.Errorf("bootstrap: %s", err)
+	}
+
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	lc.period = slot / slotsPerPeriod
+	lc.current = committee
+	lc.finalized = &b.Header
+	lc.head = &b.Header
+	log.Printf("light client bootstrapped at slot %d, block #%d", slot, b.Header.Execution.BlockNumber)
+	return nil
+}
+
+// Applies the best update for each period from ours to the current one,
+// handing down sync committees. Stops once the next committee is known.
+func (lc *LightClient) syncPeriods(ctx context.Context) error {
+	for {
+		clockPeriod := lc.net.currentSlot(uint64(time.Now().Unix())) / slotsPerPeriod
+		if lc.period >= clockPeriod && lc.next != nil {
+			return nil
+		}
+		count := uint64(1)
+		if clockPeriod > lc.period {
+			count = clockPeriod - lc.period + 1
+		}
+		if count > maxUpdatesPerRequest {
+			count = maxUpdatesPerRequest
+		}
+
+		var resp []struct{ Data lightUpdate }
+		path := fmt.Sprintf("/eth/v1/beacon/light_client/updates?start_period=%d&count=%d", lc.period, count)
+		if err := beaconGet(ctx, lc.beaconUrl, path, &resp); err != nil {
+			return err
+		}
+		period, hasNext := lc.period, lc.next != nil
+		for i := range resp {
+			if err := lc.applyUpdate(&resp[i].Data); err != nil {
+				return fmt.Errorf("update for period %d: %s", lc.period, err)
+			}
+		}
+		if lc.period == period && (lc.next != nil) == hasNext {
+			// Nothing new yet, eg early in a period
+			return nil
+		}
+	}
+}
+
+// Verifies an update, then advances the store: newer headers, and the next
+// sync committee. Rotates to the next committee once a header in its period
+// is finalized.
+func (lc *LightClient) applyUpdate(u *lightUpdate) error {
+	attested := &u.AttestedHeader
+	slot, sigSlot := uint64(attested.Beacon.Slot), uint64(u.SignatureSlot)
+	finalized := u.FinalizedHeader
+	if finalized != nil && finalized.Beacon.Slot == 0 {
+		// Empty, nothing finalized yet
+		finalized = nil
+	}
+	// Take the next committee only from a finalized header in our period,
+	// as in the spec's update_has_finalized_next_sync_committee
+	wantNext := lc.next == nil && u.NextSyncCommittee != nil && finalized != nil &&
+		slot/slotsPerPeriod == lc.period && uint64(finalized.Beacon.Slot)/slotsPerPeriod == lc.period
+	newHead := lc.head == nil || slot > uint64(lc.head.Beacon.Slot)
+	newFinal := finalized != nil && finalized.Beacon.Slot > lc.finalized.Beacon.Slot
+	if !wantNext && !newHead && !newFinal {
+		return nil
+	}
+
+	// Check the signature, by the committee of the signature's period
+	if sigSlot <= slot {
+		return fmt.Errorf("signature slot %d not after attested slot %d", sigSlot, slot)
+	}
+	var committee *syncCommittee
+	switch sigSlot / slotsPerPeriod {
+	case lc.period:
+		committee = lc.current
+	case lc.period + 1:
+		committee = lc.next
+	}
+	if committee == nil {
+		return fmt.Errorf("no known sync committee for slot %d", sigSlot)
+	}
+	if err := lc.verifyHeader(attested); err != nil {
+		return fmt.Errorf("attested header: %s", err)
+	}
+	if err := lc.verifySignature(committee, attested, &u.SyncAggregate, sigSlot); err != nil {
+		return err
+	}
+
+	// Check everything else against the signed header's state
+	depth := lc.net.stateDepth(slot)
+	if finalized != nil {
+		if !sszVerifyBranch(finalized.Beacon.hashTreeRoot(), u.FinalityBranch,
+			depth+1, stateIndexFinalizedRoot, attested.Beacon.StateRoot) {
+			return fmt.Errorf("invalid finality proof")
+		}
+		if err := lc.verifyHeader(finalized); err != nil {
+			return fmt.Errorf("finalized header: %s", err)
+		}
+	}
+	var next *syncCommittee
+	if wantNext {
+		if !sszVerifyBranch(u.NextSyncCommittee.hashTreeRoot(), u.NextSyncCommitteeBranch,
+			depth, stateIndexNextCommittee, attested.Beacon.StateRoot) {
+			return fmt.Errorf("invalid next sync committee proof")
+		}
+		var err error
+		if next, err = decodeSyncCommittee(u.NextSyncCommittee); err != nil {
+			return fmt.Errorf("next sync committee: %s", err)
+		}
+	}
+
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	if newFinal {
+		lc.finalized = finalized
+		if uint64(finalized.Beacon.Slot)/slotsPerPeriod == lc.period+1 && lc.next != nil {
+			lc.period++
+			lc.current, lc.next = lc.next, nil
+			log.Printf("light client advanced to sync committee period %d", lc.period)
+		}
+	}
+	if next != nil && lc.next == nil && slot/slotsPerPeriod == lc.period {
+		lc.next = next
+	}
+	if newHead {
+		lc.head = attested
+	}
+	return nil
+}
+
+// Checks that a header's execution payload header is proven by its body root.
+func (lc *LightClient) verifyHeader(h *lightHeader) error {
+	slot := uint64(h.Beacon.Slot)
+	if h.Execution == nil {
+		return fmt.Errorf("slot %d has no execution header, pre-Capella", slot)
+	}
+	deneb := lc.net.isActive("deneb", slot)
+	if !sszVerifyBranch(h.Execution.hashTreeRoot(deneb), h.ExecutionBranch,
+		bodyDepth, bodyIndexExecution, h.Beacon.BodyRoot) {
+		return fmt.Errorf("slot %d: invalid execution header proof", slot)
+	}
+	return nil
+}
+
+// Checks the sync committee signature over a header. Requires a 2/3
+// supermajority, so that a few bad committee members can't sign a fork.
+func (lc *LightClient) verifySignature(committee *syncCommittee, h *lightHeader, agg *syncAggregate, sigSlot uint64) error {
+	if len(agg.Bits) != syncCommitteeSize/8 {
+		return fmt.Errorf("invalid sync committee bits")
+	}
+	var signers []*bls12381.PointG1
+	for i, pk := range committee.pubkeys {
+		if agg.Bits[i/8]>>(i%8)&1 == 1 {
+			signers = append(signers, pk)
+		}
+	}
+	if 3*len(signers) < 2*syncCommitteeSize {
+		return fmt.Errorf("only %d of %d sync committee members signed", len(signers), syncCommitteeSize)
+	}
+	root := lc.net.syncSigningRoot(h.Beacon.hashTreeRoot(), sigSlot)
+	if err := blsFastAggregateVerify(signers, root[:], agg.Signature); err != nil {
+		return fmt.Errorf("slot %d: %s", h.Beacon.Slot, err)
+	}
+	return nil
+}
+
+func decodeSyncCommittee(s *syncCommitteeJson) (*syncCommittee, error) {
+	if len(s.Pubkeys) != syncCommitteeSize {
+		return nil, fmt.Errorf("want %d pubkeys, got %d", syncCommitteeSize, len(s.Pubkeys))
+	}
+	ret := &syncCommittee{pubkeys: make([]*bls12381.PointG1, len(s.Pubkeys))}
+	for i, pk := range s.Pubkeys {
+		p, err := blsDecodeG1(pk)
+		if err != nil {
+			return nil, fmt.Errorf("pubkey %d: %s", i, err)
+		}
+		ret.pubkeys[i] = p
+	}
+	return ret, nil
+}
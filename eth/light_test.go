@@ -0,0 +1,352 @@
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/bls12381"
+)
+
+// Light client fixtures, built and signed here rather than recorded, so that
+// every proof and signature is checkable offline. They follow mainnet's fork
+// schedule and tree depths: slots are in Fulu, after Electra deepened the
+// state tree.
+
+// Start of the sync committee period used by the fixtures
+const testPeriodStart = 1610 * slotsPerPeriod
+
+// Beacon API numbers are decimal strings
+func (d decUint64) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + strconv.FormatUint(uint64(d), 10) + `"`), nil
+}
+
+func (d *decBig) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + (*big.Int)(d).String() + `"`), nil
+}
+
+// Sync committee of 512 members drawn from a few keys. Real committees can
+// repeat validators too.
+type testCommittee struct {
+	keys []*big.Int
+	json syncCommitteeJson
+}
+
+func newTestCommittee(seed string) *testCommittee {
+	g1 := bls12381.NewG1()
+	var distinct []*big.Int
+	for i := 0; i < 4; i++ {
+		k := new(big.Int).SetBytes(crypto.Keccak256([]byte(seed), []byte{byte(i)}))
+		distinct = append(distinct, k.Mod(k, g1.Q()))
+	}
+	c := &testCommittee{}
+	agg := g1.Zero()
+	for i := 0; i < syncCommitteeSize; i++ {
+		k := distinct[i%len(distinct)]
+		pk := g1.MulScalar(g1.New(), g1.One(), k)
+		g1.Add(agg, agg, pk)
+		c.keys = append(c.keys, k)
+		c.json.Pubkeys = append(c.json.Pubkeys, blsCompressG1(pk))
+	}
+	c.json.AggregatePubkey = blsCompressG1(agg)
+	return c
+}
+
+// Signs a header as of sigSlot. Every fourth member abstains, leaving a
+// 3/4 supermajority.
+func (c *testCommittee) sign(h *lightHeader, sigSlot uint64) syncAggregate {
+	g2 := bls12381.NewG2()
+	bits := make([]byte, syncCommitteeSize/8)
+	sum := new(big.Int)
+	for i, k := range c.keys {
+		if i%4 != 3 {
+			bits[i/8] |= 1 << (i % 8)
+			sum.Add(sum, k)
+		}
+	}
+	root := beaconNetworks[1].syncSigningRoot(h.Beacon.hashTreeRoot(), sigSlot)
+	msg, err := blsHashToG2(root[:], blsDst)
+	if err != nil {
+		panic(err)
+	}
+	sig := g2.MulScalar(g2.New(), msg, sum.Mod(sum, g2.Q()))
+	return syncAggregate{Bits: bits, Signature: blsCompressG2(sig)}
+}
+
+func blsCompressG1(p *bls12381.PointG1) []byte {
+	raw := bls12381.NewG1().ToBytes(p)
+	out := raw[:48]
+	if new(big.Int).SetBytes(raw[48:]).Cmp(blsHalfP) > 0 {
+		out[0] |= 0x20
+	}
+	out[0] |= 0x80
+	return out
+}
+
+func blsCompressG2(p *bls12381.PointG2) []byte {
+	raw := bls12381.NewG2().ToBytes(p)
+	out := raw[:96]
+	y := fp2{new(big.Int).SetBytes(raw[144:]), new(big.Int).SetBytes(raw[96:144])}
+	if y.lexLarger() {
+		out[0] |= 0x20
+	}
+	out[0] |= 0x80
+	return out
+}
+
+// Merkle tree layers, leaves first. Leaves not given are filler.
+type testTree [][]common.Hash
+
+func newTestTree(depth int, leaves map[uint64]common.Hash) testTree {
+	layer := make([]common.Hash, 1<<depth)
+	for i := range layer {
+		layer[i] = sszUint64(uint64(1000 + i))
+	}
+	for i, leaf := range leaves {
+		layer[i] = leaf
+	}
+	tree := testTree{layer}
+	for len(layer) > 1 {
+		next := make([]common.Hash, len(layer)/2)
+		for i := range next {
+			next[i] = sszHashPair(layer[2*i], layer[2*i+1])
+		}
+		tree = append(tree, next)
+		layer = next
+	}
+	return tree
+}
+
+func (t testTree) root() common.Hash {
+	return t[len(t)-1][0]
+}
+
+func (t testTree) branch(index uint64) []common.Hash {
+	ret := make([]common.Hash, len(t)-1)
+	for i := range ret {
+		ret[i] = t[i][(index>>uint(i))^1]
+	}
+	return ret
+}
+
+// Header at slot, with an execution header proven against its body root.
+func testLightHeader(slot uint64, stateRoot common.Hash) lightHeader {
+	ex := &executionHeader{
+		ParentHash:   common.BytesToHash(crypto.Keccak256(sszUint64(slot - 1).Bytes())),
+		FeeRecipient: common.HexToAddress("0x95222290dd7278aa3ddd389cc1e1d165cc4bafe5"),
+		StateRoot:    common.BytesToHash(crypto.Keccak256(sszUint64(slot).Bytes())),
+		BlockHash:    common.BytesToHash(crypto.Keccak256(sszUint64(slot).Bytes(), []byte("block"))),
+		LogsBloom:    make([]byte, 256),
+		BlockNumber:  decUint64(slot - 10000000),
+		GasLimit:     36000000,
+		GasUsed:      12000000,
+		Timestamp:    decUint64(beaconNetworks[1].genesisTime + slot*slotSeconds),
+		ExtraData:    []byte("beaverbuild.org"),
+		BlobGasUsed:  131072,
+	}
+	(*big.Int)(&ex.BaseFeePerGas).SetUint64(1e9)
+	body := newTestTree(bodyDepth, map[uint64]common.Hash{bodyIndexExecution: ex.hashTreeRoot(true)})
+	return lightHeader{
+		Beacon: beaconHeader{
+			Slot:          decUint64(slot),
+			ProposerIndex: 1234,
+			ParentRoot:    common.HexToHash("0x01"),
+			StateRoot:     stateRoot,
+			BodyRoot:      body.root(),
+		},
+		Execution:       ex,
+		ExecutionBranch: body.branch(bodyIndexExecution),
+	}
+}
+
+type lightFixture struct {
+	current, next *testCommittee
+	bootstrap     lightBootstrap
+	checkpoint    common.Hash
+	// Update with the next committee and a finalized header, both in the
+	// bootstrap's period
+	update lightUpdate
+}
+
+func newLightFixture() *lightFixture {
+	f := &lightFixture{current: newTestCommittee("current"), next: newTestCommittee("next")}
+	depth := beaconNetworks[1].stateDepth(testPeriodStart)
+
+	state := newTestTree(depth, map[uint64]common.Hash{
+		stateIndexCurrentCommittee: f.current.json.hashTreeRoot(),
+	})
+	f.bootstrap = lightBootstrap{
+		Header:                     testLightHeader(testPeriodStart+64, state.root()),
+		CurrentSyncCommittee:       f.current.json,
+		CurrentSyncCommitteeBranch: state.branch(stateIndexCurrentCommittee),
+	}
+	f.checkpoint = f.bootstrap.Header.Beacon.hashTreeRoot()
+
+	finalized := testLightHeader(testPeriodStart+4096, common.HexToHash("0x02"))
+	finalizedEpoch := sszUint64(uint64(finalized.Beacon.Slot) / slotsPerEpoch)
+	// The finalized root sits in the finalized checkpoint, after its epoch
+	state = newTestTree(depth, map[uint64]common.Hash{
+		stateIndexCurrentCommittee:  f.current.json.hashTreeRoot(),
+		stateIndexNextCommittee:     f.next.json.hashTreeRoot(),
+		stateIndexFinalizedRoot / 2: sszHashPair(finalizedEpoch, finalized.Beacon.hashTreeRoot()),
+	})
+	attested := testLightHeader(testPeriodStart+4160, state.root())
+	sigSlot := uint64(attested.Beacon.Slot) + 1
+	f.update = lightUpdate{
+		AttestedHeader:          attested,
+		NextSyncCommittee:       &f.next.json,
+		NextSyncCommitteeBranch: state.branch(stateIndexNextCommittee),
+		FinalizedHeader:         &finalized,
+		FinalityBranch:          append([]common.Hash{finalizedEpoch}, state.branch(stateIndexFinalizedRoot/2)...),
+		SyncAggregate:           f.current.sign(&attested, sigSlot),
+		SignatureSlot:           decUint64(sigSlot),
+	}
+	return f
+}
+
+// Round-trips through JSON, as served by a beacon node. Also gives each
+// caller its own copy to tamper with.
+func (f *lightFixture) updateJson(t *testing.T) *lightUpdate {
+	b, err := json.Marshal(&f.update)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ret lightUpdate
+	if err := json.Unmarshal(b, &ret); err != nil {
+		t.Fatal(err)
+	}
+	return &ret
+}
+
+// Serves the fixture's bootstrap, and returns a light client bootstrapped
+// from it.
+func (f *lightFixture) client(t *testing.T) *LightClient {
+	b, err := json.Marshal(map[string]interface{}{"data": &f.bootstrap})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/eth/v1/beacon/light_client/bootstrap/"+f.checkpoint.Hex() {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(b)
+	}))
+	t.Cleanup(hs.Close)
+
+	lc, err := NewLightClient(hs.URL, f.checkpoint, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lc.bootstrap(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return lc
+}
+
+func TestLightClientUpdate(t *testing.T) {
+	f := newLightFixture()
+	lc := f.client(t)
+	if lc.period != testPeriodStart/slotsPerPeriod || lc.head.Beacon.Slot != f.bootstrap.Header.Beacon.Slot {
+		t.Fatalf("bootstrapped to period %d slot %d", lc.period, lc.head.Beacon.Slot)
+	}
+
+	if err := lc.applyUpdate(f.updateJson(t)); err != nil {
+		t.Fatal(err)
+	}
+	if lc.next == nil {
+		t.Error("next sync committee not taken")
+	}
+	if lc.head.Beacon.Slot != f.update.AttestedHeader.Beacon.Slot {
+		t.Errorf("head at slot %d, want %d", lc.head.Beacon.Slot, f.update.AttestedHeader.Beacon.Slot)
+	}
+	if lc.finalized.Beacon.Slot != f.update.FinalizedHeader.Beacon.Slot {
+		t.Errorf("finalized at slot %d, want %d", lc.finalized.Beacon.Slot, f.update.FinalizedHeader.Beacon.Slot)
+	}
+
+	// The fixture is long past, so stale until its clock is moved up
+	if _, _, err := lc.TrustedHash(); err == nil || !strings.Contains(err.Error(), "stale") {
+		t.Errorf("got %v, want a stale head error", err)
+	}
+	lc.head.Execution.Timestamp = decUint64(time.Now().Unix())
+	ex := f.update.AttestedHeader.Execution
+	number, hash, err := lc.TrustedHash()
+	if err != nil || number != uint64(ex.BlockNumber) || hash != ex.BlockHash {
+		t.Errorf("got #%d %s %v, want #%d %s", number, hash, err, ex.BlockNumber, ex.BlockHash)
+	}
+}
+
+// Every bit of an update is either signed or proven. Flipping one must fail.
+func TestLightClientUpdateTampered(t *testing.T) {
+	f := newLightFixture()
+	lc := f.client(t)
+	tampers := map[string]func(u *lightUpdate){
+		"signature":          func(u *lightUpdate) { u.SyncAggregate.Signature[50] ^= 1 },
+		"signer bits":        func(u *lightUpdate) { u.SyncAggregate.Bits[0] ^= 1 },
+		"signature slot":     func(u *lightUpdate) { u.SignatureSlot += slotsPerPeriod },
+		"attested slot":      func(u *lightUpdate) { u.AttestedHeader.Beacon.Slot ^= 1 },
+		"attested state":     func(u *lightUpdate) { u.AttestedHeader.Beacon.StateRoot[0] ^= 1 },
+		"execution header":   func(u *lightUpdate) { u.AttestedHeader.Execution.StateRoot[0] ^= 1 },
+		"execution branch":   func(u *lightUpdate) { u.AttestedHeader.ExecutionBranch[0][0] ^= 1 },
+		"next committee":     func(u *lightUpdate) { u.NextSyncCommittee.Pubkeys[7][47] ^= 1 },
+		"next branch":        func(u *lightUpdate) { u.NextSyncCommitteeBranch[2][31] ^= 1 },
+		"finalized header":   func(u *lightUpdate) { u.FinalizedHeader.Beacon.ProposerIndex ^= 1 },
+		"finality branch":    func(u *lightUpdate) { u.FinalityBranch[0][0] ^= 1 },
+		"finalized payload":  func(u *lightUpdate) { u.FinalizedHeader.Execution.BlockNumber ^= 1 },
+		"truncated branch":   func(u *lightUpdate) { u.FinalityBranch = u.FinalityBranch[1:] },
+		"missing execution":  func(u *lightUpdate) { u.AttestedHeader.Execution = nil },
+		"too few signatures": func(u *lightUpdate) { u.SyncAggregate.Bits[0] = 0 },
+	}
+	for name, tamper := range tampers {
+		u := f.updateJson(t)
+		tamper(u)
+		if err := lc.applyUpdate(u); err == nil {
+			t.Errorf("%s: tampered update accepted", name)
+		}
+	}
+	if lc.head.Beacon.Slot != f.bootstrap.Header.Beacon.Slot || lc.next != nil {
+		t.Fatal("rejected updates changed the store")
+	}
+}
+
+func TestLightClientBootstrapTampered(t *testing.T) {
+	f := newLightFixture()
+	f.bootstrap.CurrentSyncCommitteeBranch[0][0] ^= 1
+	b, _ := json.Marshal(map[string]interface{}{"data": &f.bootstrap})
+	hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(b)
+	}))
+	defer hs.Close()
+
+	lc, _ := NewLightClient(hs.URL, f.checkpoint, 1)
+	err := lc.bootstrap(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "sync committee proof") {
+		t.Fatalf("got %v, want an invalid proof error", err)
+	}
+}
+
+// The next committee only counts if it comes with a finalized header from
+// the same period.
+func TestLightClientNextNeedsFinality(t *testing.T) {
+	f := newLightFixture()
+	lc := f.client(t)
+	u := f.updateJson(t)
+	u.FinalizedHeader, u.FinalityBranch = nil, nil
+	if err := lc.applyUpdate(u); err != nil {
+		t.Fatal(err)
+	}
+	if lc.next != nil {
+		t.Fatal("took next sync committee without finality")
+	}
+	if lc.head.Beacon.Slot != u.AttestedHeader.Beacon.Slot {
+		t.Fatal("head not advanced")
+	}
+}
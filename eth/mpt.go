@@ -0,0 +1,131 @@
+package eth
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Checks a Merkle-Patricia proof, as returned by eth_getProof, for a key in
+// a secure trie (keyed by keccak). Returns the value at key, or nil if the
+// proof shows the key is absent. Standalone, since the go-ethereum trie
+// package drags in the whole database layer.
+func verifyProof(root common.Hash, key []byte, proof []hexutil.Bytes) ([]byte, error) {
+	nodes := make(map[common.Hash][]byte, len(proof))
+	for _, n := range proof {
+		nodes[crypto.Keccak256Hash(n)] = n
+	}
+	path := keyNibbles(crypto.Keccak256(key))
+
+	node, ok := nodes[root]
+	if !ok {
+		if root == emptyTrieRoot {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("proof missing root node")
+	}
+	for {
+		elems, err := splitNode(node)
+		if err != nil {
+			return nil, err
+		}
+
+		var child []byte
+		switch len(elems) {
+		case 17:
+			// Branch. Keys are fixed-length hashes, so never ends here.
+			if len(path) == 0 {
+				return nil, fmt.Errorf("proof key ends at branch")
+			}
+			child, path = elems[path[0]], path[1:]
+		case 2:
+			// Leaf or extension, with a hex-prefix encoded partial path
+			var partial []byte
+			if err := rlp.DecodeBytes(elems[0], &partial); err != nil {
+				return nil, err
+			}
+			nibbles, isLeaf := compactToNibbles(partial)
+			if isLeaf {
+				if !bytes.Equal(nibbles, path) {
+					return nil, nil
+				}
+				var val []byte
+				err := rlp.DecodeBytes(elems[1], &val)
+				return val, err
+			}
+			if !bytes.HasPrefix(path, nibbles) {
+				return nil, nil
+			}
+			child, path = elems[1], path[len(nibbles):]
+		default:
+			return nil, fmt.Errorf("invalid trie node with %d items", len(elems))
+		}
+
+		// Child is a hash reference, empty, or a small node embedded inline
+		kind, content, _, err := rlp.Split(child)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case kind == rlp.List:
+			node = child
+		case len(content) == 0:
+			return nil, nil
+		case len(content) == 32:
+			node, ok = nodes[common.BytesToHash(content)]
+			if !ok {
+				return nil, fmt.Errorf("proof missing node %x", content)
+			}
+		default:
+			return nil, fmt.Errorf("invalid trie child reference")
+		}
+	}
+}
+
+var emptyTrieRoot = crypto.Keccak256Hash([]byte{0x80})
+
+// Splits an RLP list node into its raw, still-encoded items.
+func splitNode(node []byte) ([][]byte, error) {
+	content, _, err := rlp.SplitList(node)
+	if err != nil {
+		return nil, err
+	}
+	var ret [][]byte
+	for len(content) > 0 {
+		_, _, rest, err := rlp.Split(content)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, content[:len(content)-len(rest)])
+		content = rest
+	}
+	return ret, nil
+}
+
+func keyNibbles(key []byte) []byte {
+	ret := make([]byte, len(key)*2)
+	for i, b := range key {
+		ret[2*i] = b >> 4
+		ret[2*i+1] = b & 0x0f
+	}
+	return ret
+}
+
+// Decodes a hex-prefix encoded path. The first nibble flags a leaf (2) and
+// an odd length (1).
+func compactToNibbles(compact []byte) ([]byte, bool) {
+	if len(compact) == 0 {
+		return nil, false
+	}
+	nibbles := keyNibbles(compact)
+	flag := nibbles[0]
+	isLeaf := flag&2 != 0
+	if flag&1 != 0 {
+		return nibbles[1:], isLeaf
+	}
+	return nibbles[2:], isLeaf
+}
@@ -0,0 +1,136 @@
+package eth
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Collects proof nodes from trie.Prove, root first
+type proofList []hexutil.Bytes
+
+func (p *proofList) Put(key []byte, value []byte) error {
+	*p = append(*p, common.CopyBytes(value))
+	return nil
+}
+
+func (p *proofList) Delete(key []byte) error {
+	return fmt.Errorf("not supported")
+}
+
+func testKey(i int) []byte {
+	var ret [8]byte
+	binary.BigEndian.PutUint64(ret[:], uint64(i))
+	return ret[:]
+}
+
+func testValue(i int) []byte {
+	return bytes.Repeat([]byte{byte(i)}, 40)
+}
+
+// Secure trie with keys 0 to n-1, via go-ethereum's implementation
+func testTrie(t *testing.T, n int) *trie.Trie {
+	tr, err := trie.New(common.Hash{}, common.Hash{}, trie.NewDatabase(rawdb.NewMemoryDatabase()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		tr.Update(crypto.Keccak256(testKey(i)), testValue(i))
+	}
+	return tr
+}
+
+func testProof(t *testing.T, tr *trie.Trie, key []byte) proofList {
+	var proof proofList
+	if err := tr.Prove(crypto.Keccak256(key), 0, &proof); err != nil {
+		t.Fatal(err)
+	}
+	return proof
+}
+
+func TestVerifyProof(t *testing.T) {
+	tr := testTrie(t, 500)
+	root := tr.Hash()
+	for _, i := range []int{0, 1, 77, 499} {
+		val, err := verifyProof(root, testKey(i), testProof(t, tr, testKey(i)))
+		if err != nil {
+			t.Fatalf("key %d: %s", i, err)
+		}
+		if !bytes.Equal(val, testValue(i)) {
+			t.Fatalf("key %d: got %x, want %x", i, val, testValue(i))
+		}
+	}
+}
+
+func TestVerifyProofAbsent(t *testing.T) {
+	tr := testTrie(t, 500)
+	for _, i := range []int{500, 1000, 123456} {
+		val, err := verifyProof(tr.Hash(), testKey(i), testProof(t, tr, testKey(i)))
+		if err != nil || val != nil {
+			t.Fatalf("key %d: got %x %v, want proven absent", i, val, err)
+		}
+	}
+
+	// Empty trie needs no proof
+	val, err := verifyProof(emptyTrieRoot, testKey(0), nil)
+	if err != nil || val != nil {
+		t.Fatalf("empty trie: got %x %v", val, err)
+	}
+}
+
+func TestVerifyProofTampered(t *testing.T) {
+	tr := testTrie(t, 500)
+	root := tr.Hash()
+	proof := testProof(t, tr, testKey(77))
+
+	// Change the value in the leaf, last in the proof
+	leaf := proof[len(proof)-1]
+	ix := bytes.Index(leaf, testValue(77))
+	if ix < 0 {
+		t.Fatal("value not found in leaf")
+	}
+	tampered := append(proofList{}, proof...)
+	tampered[len(proof)-1] = common.CopyBytes(leaf)
+	tampered[len(proof)-1][ix] ^= 1
+	if val, err := verifyProof(root, testKey(77), tampered); err == nil || !strings.Contains(err.Error(), "missing node") {
+		t.Fatalf("tampered leaf: got %x %v, want missing node", val, err)
+	}
+
+	// Change the root
+	tampered = append(proofList{}, proof...)
+	tampered[0] = common.CopyBytes(proof[0])
+	tampered[0][len(tampered[0])-1] ^= 1
+	if val, err := verifyProof(root, testKey(77), tampered); err == nil || !strings.Contains(err.Error(), "missing root node") {
+		t.Fatalf("tampered root: got %x %v, want missing root node", val, err)
+	}
+
+	// Proof for a different key can't stand in
+	other := testProof(t, tr, testKey(78))
+	if val, err := verifyProof(root, testKey(77), other); err == nil {
+		t.Fatalf("wrong key's proof: got %x, want error", val)
+	}
+}
+
+func TestVerifyProofMissingNode(t *testing.T) {
+	tr := testTrie(t, 500)
+	proof := testProof(t, tr, testKey(77))
+	if len(proof) < 3 {
+		t.Fatalf("want a deeper proof, got %d nodes", len(proof))
+	}
+
+	for drop := range proof {
+		partial := append(append(proofList{}, proof[:drop]...), proof[drop+1:]...)
+		val, err := verifyProof(tr.Hash(), testKey(77), partial)
+		if err == nil || !strings.Contains(err.Error(), "missing") {
+			t.Fatalf("without node %d: got %x %v, want missing node", drop, val, err)
+		}
+	}
+}
@@ -0,0 +1,390 @@
+package eth
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// A vm.StateDB that fetches accounts, code and storage on demand via
+// eth_getProof and eth_getCode, verifying each against a trusted state root.
+// Writes stay local and are discarded. The first fetch or verification
+// error is kept in err, and reads return zero values after that.
+type proofState struct {
+	ctx    context.Context
+	client *Client
+	block  *big.Int
+	root   common.Hash
+
+	accounts map[common.Address]*proofAccount
+	err      error
+
+	// Undo log for Snapshot and RevertToSnapshot
+	journal []func()
+	refund  uint64
+
+	accessAddrs map[common.Address]bool
+	accessSlots map[common.Address]map[common.Hash]bool
+}
+
+type proofAccount struct {
+	exists      bool
+	nonce       uint64
+	balance     *big.Int
+	codeHash    common.Hash
+	storageRoot common.Hash
+	code        []byte
+	codeLoaded  bool
+	// Verified or locally written slots
+	storage map[common.Hash]common.Hash
+	// Verified slots, before local writes
+	committed map[common.Hash]common.Hash
+	suicided  bool
+}
+
+// Response to eth_getProof
+type accountProof struct {
+	AccountProof []hexutil.Bytes `json:"accountProof"`
+	StorageProof []struct {
+		Key   hexutil.Bytes   `json:"key"`
+		Proof []hexutil.Bytes `json:"proof"`
+	} `json:"storageProof"`
+}
+
+func newProofState(ctx context.Context, client *Client, header *types.Header) *proofState {
+	return &proofState{
+		ctx:         ctx,
+		client:      client,
+		block:       header.Number,
+		root:        header.Root,
+		accounts:    make(map[common.Address]*proofAccount),
+		accessAddrs: make(map[common.Address]bool),
+		accessSlots: make(map[common.Address]map[common.Hash]bool),
+	}
+}
+
+func (s *proofState) fail(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+func (s *proofState) getProof(addr common.Address, slots []common.Hash) (*accountProof, error) {
+	keys := make([]string, len(slots))
+	for i, k := range slots {
+		keys[i] = k.Hex()
+	}
+	var res accountProof
+	err := s.client.Rpc.CallContext(s.ctx, &res, "eth_getProof", addr, keys, hexutil.EncodeBig(s.block))
+	return &res, err
+}
+
+func (s *proofState) account(addr common.Address) *proofAccount {
+	if acc, ok := s.accounts[addr]; ok {
+		return acc
+	}
+	acc := &proofAccount{
+		balance:   new(big.Int),
+		storage:   make(map[common.Hash]common.Hash),
+		committed: make(map[common.Hash]common.Hash),
+	}
+	s.accounts[addr] = acc
+	if s.err != nil {
+		return acc
+	}
+
+	res, err := s.getProof(addr, nil)
+	if err != nil {
+		s.fail(fmt.Errorf("eth_getProof %s: %s", addr, err))
+		return acc
+	}
+	val, err := verifyProof(s.root, addr.Bytes(), res.AccountProof)
+	if err != nil {
+		s.fail(fmt.Errorf("invalid account proof for %s: %s", addr, err))
+		return acc
+	}
+	if val == nil {
+		// Proven absent
+		return acc
+	}
+	var sa types.StateAccount
+	if err := rlp.DecodeBytes(val, &sa); err != nil {
+		s.fail(fmt.Errorf("invalid account %s: %s", addr, err))
+		return acc
+	}
+	acc.exists = true
+	acc.nonce = sa.Nonce
+	acc.balance = sa.Balance
+	acc.codeHash = common.BytesToHash(sa.CodeHash)
+	acc.storageRoot = sa.Root
+	return acc
+}
+
+func (s *proofState) committedSlot(addr common.Address, acc *proofAccount, slot common.Hash) common.Hash {
+	if v, ok := acc.committed[slot]; ok {
+		return v
+	}
+	var ret common.Hash
+	if acc.exists && acc.storageRoot != emptyTrieRoot && s.err == nil {
+		ret = s.fetchSlot(addr, acc, slot)
+	}
+	acc.committed[slot] = ret
+	return ret
+}
+
+func (s *proofState) fetchSlot(addr common.Address, acc *proofAccount, slot common.Hash) common.Hash {
+	res, err := s.getProof(addr, []common.Hash{slot})
+	if err != nil {
+		s.fail(fmt.Errorf("eth_getProof %s slot %s: %s", addr, slot, err))
+		return common.Hash{}
+	} else if len(res.StorageProof) != 1 {
+		s.fail(fmt.Errorf("eth_getProof %s: missing storage proof", addr))
+		return common.Hash{}
+	}
+	val, err := verifyProof(acc.storageRoot, slot.Bytes(), res.StorageProof[0].Proof)
+	if err != nil {
+		s.fail(fmt.Errorf("invalid storage proof for %s slot %s: %s", addr, slot, err))
+		return common.Hash{}
+	}
+	if val == nil {
+		return common.Hash{}
+	}
+	// Slots are stored RLP-encoded, with leading zeros trimmed
+	var content []byte
+	if err := rlp.DecodeBytes(val, &content); err != nil {
+		s.fail(fmt.Errorf("invalid storage value for %s slot %s: %s", addr, slot, err))
+		return common.Hash{}
+	}
+	return common.BytesToHash(content)
+}
+
+func (s *proofState) CreateAccount(addr common.Address) {
+	acc := s.account(addr)
+	prev := *acc
+	s.journal = append(s.journal, func() { *acc = prev })
+	// Like geth, keep the balance of a pre-funded address
+	*acc = proofAccount{
+		exists:      true,
+		balance:     prev.balance,
+		storage:     make(map[common.Hash]common.Hash),
+		committed:   make(map[common.Hash]common.Hash),
+		codeHash:    common.BytesToHash(emptyCodeHash),
+		storageRoot: emptyTrieRoot,
+	}
+}
+
+var emptyCodeHash = crypto.Keccak256(nil)
+
+func (s *proofState) SubBalance(addr common.Address, amount *big.Int) {
+	s.setBalance(addr, new(big.Int).Sub(s.GetBalance(addr), amount))
+}
+
+func (s *proofState) AddBalance(addr common.Address, amount *big.Int) {
+	s.setBalance(addr, new(big.Int).Add(s.GetBalance(addr), amount))
+}
+
+func (s *proofState) setBalance(addr common.Address, bal *big.Int) {
+	acc := s.account(addr)
+	prev := acc.balance
+	s.journal = append(s.journal, func() { acc.balance = prev })
+	acc.balance = bal
+}
+
+func (s *proofState) GetBalance(addr common.Address) *big.Int {
+	return new(big.Int).Set(s.account(addr).balance)
+}
+
+func (s *proofState) GetNonce(addr common.Address) uint64 {
+	return s.account(addr).nonce
+}
+
+func (s *proofState) SetNonce(addr common.Address, nonce uint64) {
+	acc := s.account(addr)
+	prev := acc.nonce
+	s.journal = append(s.journal, func() { acc.nonce = prev })
+	acc.nonce = nonce
+}
+
+func (s *proofState) GetCodeHash(addr common.Address) common.Hash {
+	acc := s.account(addr)
+	if !acc.exists {
+		return common.Hash{}
+	}
+	return acc.codeHash
+}
+
+func (s *proofState) GetCode(addr common.Address) []byte {
+	acc := s.account(addr)
+	if acc.codeLoaded || !acc.exists || bytes.Equal(acc.codeHash[:], emptyCodeHash) || s.err != nil {
+		return acc.code
+	}
+	var code hexutil.Bytes
+	err := s.client.Rpc.CallContext(s.ctx, &code, "eth_getCode", addr, hexutil.EncodeBig(s.block))
+	if err != nil {
+		s.fail(fmt.Errorf("eth_getCode %s: %s", addr, err))
+		return nil
+	}
+	if crypto.Keccak256Hash(code) != acc.codeHash {
+		s.fail(fmt.Errorf("code for %s does not match its proven hash", addr))
+		return nil
+	}
+	acc.code = code
+	acc.codeLoaded = true
+	return acc.code
+}
+
+func (s *proofState) SetCode(addr common.Address, code []byte) {
+	acc := s.account(addr)
+	prev := *acc
+	s.journal = append(s.journal, func() { acc.code, acc.codeHash, acc.codeLoaded = prev.code, prev.codeHash, prev.codeLoaded })
+	acc.code = code
+	acc.codeHash = crypto.Keccak256Hash(code)
+	acc.codeLoaded = true
+}
+
+func (s *proofState) GetCodeSize(addr common.Address) int {
+	return len(s.GetCode(addr))
+}
+
+func (s *proofState) AddRefund(gas uint64) {
+	prev := s.refund
+	s.journal = append(s.journal, func() { s.refund = prev })
+	s.refund += gas
+}
+
+func (s *proofState) SubRefund(gas uint64) {
+	prev := s.refund
+	s.journal = append(s.journal, func() { s.refund = prev })
+	if gas > s.refund {
+		s.refund = 0
+	} else {
+		s.refund -= gas
+	}
+}
+
+func (s *proofState) GetRefund() uint64 {
+	return s.refund
+}
+
+func (s *proofState) GetCommittedState(addr common.Address, slot common.Hash) common.Hash {
+	return s.committedSlot(addr, s.account(addr), slot)
+}
+
+func (s *proofState) GetState(addr common.Address, slot common.Hash) common.Hash {
+	acc := s.account(addr)
+	if v, ok := acc.storage[slot]; ok {
+		return v
+	}
+	return s.committedSlot(addr, acc, slot)
+}
+
+func (s *proofState) SetState(addr common.Address, slot, val common.Hash) {
+	acc := s.account(addr)
+	prev, had := acc.storage[slot]
+	s.journal = append(s.journal, func() {
+		if had {
+			acc.storage[slot] = prev
+		} else {
+			delete(acc.storage, slot)
+		}
+	})
+	acc.storage[slot] = val
+}
+
+func (s *proofState) Suicide(addr common.Address) bool {
+	acc := s.account(addr)
+	if !acc.exists {
+		return false
+	}
+	prevSuicided, prevBal := acc.suicided, acc.balance
+	s.journal = append(s.journal, func() { acc.suicided, acc.balance = prevSuicided, prevBal })
+	acc.suicided = true
+	acc.balance = new(big.Int)
+	return true
+}
+
+func (s *proofState) HasSuicided(addr common.Address) bool {
+	return s.account(addr).suicided
+}
+
+func (s *proofState) Exist(addr common.Address) bool {
+	return s.account(addr).exists
+}
+
+func (s *proofState) Empty(addr common.Address) bool {
+	acc := s.account(addr)
+	return !acc.exists || (acc.nonce == 0 && acc.balance.Sign() == 0 && bytes.Equal(acc.codeHash[:], emptyCodeHash))
+}
+
+func (s *proofState) PrepareAccessList(sender common.Address, dest *common.Address, precompiles []common.Address, txAccesses types.AccessList) {
+	s.AddAddressToAccessList(sender)
+	if dest != nil {
+		s.AddAddressToAccessList(*dest)
+	}
+	for _, addr := range precompiles {
+		s.AddAddressToAccessList(addr)
+	}
+	for _, el := range txAccesses {
+		s.AddAddressToAccessList(el.Address)
+		for _, key := range el.StorageKeys {
+			s.AddSlotToAccessList(el.Address, key)
+		}
+	}
+}
+
+func (s *proofState) AddressInAccessList(addr common.Address) bool {
+	return s.accessAddrs[addr]
+}
+
+func (s *proofState) SlotInAccessList(addr common.Address, slot common.Hash) (bool, bool) {
+	return s.accessAddrs[addr], s.accessSlots[addr][slot]
+}
+
+func (s *proofState) AddAddressToAccessList(addr common.Address) {
+	if s.accessAddrs[addr] {
+		return
+	}
+	s.accessAddrs[addr] = true
+	s.journal = append(s.journal, func() { delete(s.accessAddrs, addr) })
+}
+
+func (s *proofState) AddSlotToAccessList(addr common.Address, slot common.Hash) {
+	s.AddAddressToAccessList(addr)
+	slots := s.accessSlots[addr]
+	if slots == nil {
+		slots = make(map[common.Hash]bool)
+		s.accessSlots[addr] = slots
+	}
+	if slots[slot] {
+		return
+	}
+	slots[slot] = true
+	s.journal = append(s.journal, func() { delete(slots, slot) })
+}
+
+func (s *proofState) Snapshot() int {
+	return len(s.journal)
+}
+
+func (s *proofState) RevertToSnapshot(id int) {
+	for i := len(s.journal) - 1; i >= id; i-- {
+		s.journal[i]()
+	}
+	s.journal = s.journal[:id]
+}
+
+// Logs and preimages don't matter for a read-only call.
+func (s *proofState) AddLog(*types.Log) {}
+
+func (s *proofState) AddPreimage(common.Hash, []byte) {}
+
+func (s *proofState) ForEachStorage(common.Address, func(common.Hash, common.Hash) bool) error {
+	return fmt.Errorf("not supported")
+}
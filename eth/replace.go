@@ -0,0 +1,93 @@
+package eth
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Geth and most other nodes only replace a pending transaction if both the
+// fee cap and tip rise by at least 10%.
+const replaceBumpPercent = 10
+
+// Resends a pending transaction at the same nonce with higher fees.
+func (c *Client) SpeedUpTx(tx *types.Transaction, signer Signer) (*types.Transaction, error) {
+	fee, err := c.replacementFee(tx)
+	if err != nil {
+		return nil, err
+	}
+	chainID, err := replaceChainID(tx)
+	if err != nil {
+		return nil, err
+	}
+	replacement := newTx(chainID, tx.Nonce(), tx.Gas(), tx.To(), tx.Value(), tx.Data(), tx.AccessList(), fee)
+	return c.Execute(replacement, chainID, signer)
+}
+
+// Replaces a pending transaction with a zero-value transfer to self at the
+// same nonce. Whichever lands first wins.
+func (c *Client) CancelTx(tx *types.Transaction, signer Signer) (*types.Transaction, error) {
+	fee, err := c.replacementFee(tx)
+	if err != nil {
+		return nil, err
+	}
+	chainID, err := replaceChainID(tx)
+	if err != nil {
+		return nil, err
+	}
+	self := signer.Address()
+	replacement := newTx(chainID, tx.Nonce(), 21000, &self, big.NewInt(0), nil, nil, fee)
+	return c.Execute(replacement, chainID, signer)
+}
+
+// Replacements go to the same chain as the original, even if the RPC has
+// since switched. Pre-EIP-155 transactions, eg imported ones, don't say which.
+func replaceChainID(tx *types.Transaction) (*big.Int, error) {
+	if !tx.Protected() {
+		return nil, fmt.Errorf("can't replace a transaction without a chain ID")
+	}
+	return tx.ChainId(), nil
+}
+
+// True if tx is a zero-value, no-calldata transfer to self, as sent by CancelTx.
+func IsCancelTx(tx *types.Transaction, from common.Address) bool {
+	return tx.To() != nil && *tx.To() == from && tx.Value().Sign() == 0 && len(tx.Data()) == 0
+}
+
+// Bumps fees enough to replace tx, or to the current fast preset if higher.
+func (c *Client) replacementFee(tx *types.Transaction) (Fee, error) {
+	opts, err := c.EstimateFees(context.Background())
+	if err != nil {
+		return Fee{}, err
+	}
+	market := opts.Fast
+
+	fee := Fee{
+		MaxFee: bigMax(bumpFee(tx.GasFeeCap()), market.MaxFee),
+		Tip:    bigMax(bumpFee(tx.GasTipCap()), market.Tip),
+		Legacy: market.Legacy,
+	}
+	if fee.Legacy {
+		fee.Tip = fee.MaxFee
+	} else if fee.Tip.Cmp(fee.MaxFee) > 0 {
+		fee.MaxFee = fee.Tip
+	}
+	return fee, nil
+}
+
+// Adds the minimum replacement bump, rounding up.
+func bumpFee(v *big.Int) *big.Int {
+	ret := new(big.Int).Mul(v, big.NewInt(100+replaceBumpPercent))
+	ret.Add(ret, big.NewInt(99))
+	return ret.Div(ret, big.NewInt(100))
+}
+
+func bigMax(a, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
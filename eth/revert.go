@@ -0,0 +1,190 @@
+package eth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	// Error(string), emitted by require() and revert("...")
+	selectorError = []byte{0x08, 0xc3, 0x79, 0xa0}
+	// Panic(uint256), emitted by failed assert(), overflow, etc
+	selectorPanic = []byte{0x4e, 0x48, 0x7b, 0x71}
+)
+
+// Solidity panic codes, see https://docs.soliditylang.org/en/latest/control-structures.html#panic-via-assert-and-error-via-require
+var panicReasons = map[uint64]string{
+	0x00: "generic panic",
+	0x01: "assert failed",
+	0x11: "arithmetic overflow or underflow",
+	0x12: "division or modulo by zero",
+	0x21: "invalid enum value",
+	0x22: "invalid storage byte array",
+	0x31: "pop on empty array",
+	0x32: "array index out of bounds",
+	0x41: "out of memory",
+	0x51: "call to invalid internal function",
+}
+
+// Adds an ABI for decoding calldata and custom errors, eg from --abi-dir.
+func RegisterAbi(a *abi.ABI) {
+	knownAbis = append(knownAbis, a)
+}
+
+// Registers every *.json contract ABI in a directory. Accepts either a bare
+// ABI array or a compiler artifact with an "abi" field.
+func LoadAbiDir(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		js, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var artifact struct {
+			Abi json.RawMessage `json:"abi"`
+		}
+		if json.Unmarshal(js, &artifact) == nil && len(artifact.Abi) > 0 {
+			js = artifact.Abi
+		}
+		a, err := abi.JSON(bytes.NewReader(js))
+		if err != nil {
+			return fmt.Errorf("%s: %s", path, err)
+		}
+		RegisterAbi(&a)
+	}
+	return nil
+}
+
+// Decodes revert data into a readable reason: a require() message, a panic
+// code, or a custom error from a known ABI.
+func DecodeRevert(data []byte) string {
+	if len(data) == 0 {
+		return "no reason given"
+	}
+	if len(data) < 4 {
+		return fmt.Sprintf("invalid revert data %s", hexutil.Encode(data))
+	}
+	if bytes.Equal(data[:4], selectorError) {
+		if reason, err := abi.UnpackRevert(data); err == nil {
+			return reason
+		}
+	}
+	if bytes.Equal(data[:4], selectorPanic) && len(data) == 36 {
+		code := new(big.Int).SetBytes(data[4:])
+		reason := panicReasons[code.Uint64()]
+		if reason == "" || !code.IsUint64() {
+			reason = "unknown panic"
+		}
+		return fmt.Sprintf("panic 0x%x: %s", code, reason)
+	}
+	for _, a := range knownAbis {
+		for _, e := range a.Errors {
+			if !bytes.Equal(data[:4], e.ID[:4]) {
+				continue
+			}
+			vals, err := e.Inputs.Unpack(data[4:])
+			if err != nil {
+				continue
+			}
+			args := make([]string, len(vals))
+			for i, v := range vals {
+				args[i] = fmt.Sprintf("%s=%s", e.Inputs[i].Name, formatArg(v))
+			}
+			return fmt.Sprintf("%s(%s)", e.Name, strings.Join(args, ", "))
+		}
+	}
+	return fmt.Sprintf("unknown error %s, %d bytes", hexutil.Encode(data[:4]), len(data))
+}
+
+// Replaces an eth_call or eth_estimateGas revert error with the decoded
+// reason, if the node returned revert data. Passes other errors through.
+func RevertError(err error) error {
+	if err == nil {
+		return nil
+	}
+	de, ok := err.(rpc.DataError)
+	if !ok {
+		return err
+	}
+	hex, ok := de.ErrorData().(string)
+	if !ok {
+		return err
+	}
+	data, decodeErr := hexutil.Decode(hex)
+	if decodeErr != nil {
+		return err
+	}
+	return fmt.Errorf("execution reverted: %s", DecodeRevert(data))
+}
+
+// Recovers a reverted transaction's revert reason, which receipts don't
+// include. Asks the node to trace the transaction if it supports that.
+// Otherwise re-runs it against the state before its block, which skips
+// earlier transactions in the same block, so the reason may differ; then
+// simulated is true.
+func (c *Client) RevertReason(tx *types.Transaction, from common.Address, block *big.Int) (reason string, simulated bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	reason, err := c.tracedRevertReason(ctx, tx.Hash())
+	if err == nil {
+		return reason, false
+	}
+	log.Printf("trace %s failed, re-simulating: %s", tx.Hash(), err)
+
+	msg := ethereum.CallMsg{
+		From:  from,
+		To:    tx.To(),
+		Gas:   tx.Gas(),
+		Value: tx.Value(),
+		Data:  tx.Data(),
+	}
+	parent := new(big.Int).Sub(block, big.NewInt(1))
+	_, err = c.Ec.CallContract(ctx, msg, parent)
+	if err == nil {
+		// Succeeds on its own, so it likely ran out of gas or depended on
+		// an earlier transaction in the block.
+		return "unknown, succeeds when re-run at the previous block", true
+	}
+	return RevertError(err).Error(), true
+}
+
+// Top-level call frame from debug_traceTransaction's callTracer
+type callFrame struct {
+	Output hexutil.Bytes `json:"output"`
+	Error  string        `json:"error"`
+}
+
+// Reads the revert reason from a trace of the transaction, as executed.
+func (c *Client) tracedRevertReason(ctx context.Context, hash common.Hash) (string, error) {
+	var frame callFrame
+	opts := map[string]interface{}{"tracer": "callTracer"}
+	if err := c.Rpc.CallContext(ctx, &frame, "debug_traceTransaction", hash, opts); err != nil {
+		return "", err
+	}
+	if frame.Error == "" {
+		return "", fmt.Errorf("trace shows no error")
+	} else if frame.Error != vm.ErrExecutionReverted.Error() {
+		// Eg out of gas, which has no revert data
+		return frame.Error, nil
+	}
+	return "execution reverted: " + DecodeRevert(frame.Output), nil
+}
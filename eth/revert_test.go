@@ -0,0 +1,81 @@
+package eth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Error(string) revert data for "too little received"
+var revertData = common.FromHex("08c379a0" +
+	"0000000000000000000000000000000000000000000000000000000000000020" +
+	"0000000000000000000000000000000000000000000000000000000000000013" +
+	"746f6f206c6974746c6520726563656976656400000000000000000000000000")
+
+// Serves debug_traceTransaction with a canned callTracer frame.
+type testDebugApi struct {
+	frame callFrame
+}
+
+func (api *testDebugApi) TraceTransaction(hash common.Hash, opts map[string]interface{}) callFrame {
+	return api.frame
+}
+
+// Serves eth_call, reverting with revertData.
+type testRevertingEthApi struct{}
+
+func (api *testRevertingEthApi) Call(msg map[string]interface{}, block string) (hexutil.Bytes, error) {
+	return nil, &testRevertError{}
+}
+
+type testRevertError struct{}
+
+func (e *testRevertError) Error() string          { return "execution reverted" }
+func (e *testRevertError) ErrorCode() int         { return 3 }
+func (e *testRevertError) ErrorData() interface{} { return hexutil.Encode(revertData) }
+
+func newRevertTestClient(t *testing.T, debug *testDebugApi) *Client {
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", &testRevertingEthApi{}); err != nil {
+		t.Fatal(err)
+	}
+	if debug != nil {
+		if err := server.RegisterName("debug", debug); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(server.Stop)
+	rpcClient := rpc.DialInProc(server)
+	return &Client{Ec: ethclient.NewClient(rpcClient), Rpc: rpcClient}
+}
+
+func TestRevertReason(t *testing.T) {
+	to := common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")
+	tx := types.NewTx(&types.LegacyTx{Nonce: 1, To: &to, Gas: 100000, GasPrice: big.NewInt(1)})
+	from := common.HexToAddress("0x1000")
+	block := big.NewInt(100)
+	tests := []struct {
+		name      string
+		debug     *testDebugApi
+		want      string
+		simulated bool
+	}{
+		{"traced", &testDebugApi{callFrame{revertData, "execution reverted"}},
+			"execution reverted: too little received", false},
+		{"traced out of gas", &testDebugApi{callFrame{nil, "out of gas"}}, "out of gas", false},
+		{"no debug api", nil, "execution reverted: too little received", true},
+		{"trace shows success", &testDebugApi{callFrame{}}, "execution reverted: too little received", true},
+	}
+	for _, tt := range tests {
+		c := newRevertTestClient(t, tt.debug)
+		reason, simulated := c.RevertReason(tx, from, block)
+		if reason != tt.want || simulated != tt.simulated {
+			t.Errorf("%s: got %q, simulated %v", tt.name, reason, simulated)
+		}
+	}
+}
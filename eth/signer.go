@@ -0,0 +1,278 @@
+package eth
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Signs transactions and messages for a single account.
+type Signer interface {
+	// Signing account
+	Address() common.Address
+	// Signs a transaction for a chain ID. Unsigned legacy transactions don't
+	// carry one, so it's passed separately.
+	SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+	// Signs a personal_sign style message, returning a 65-byte [R || S || V] signature
+	SignMessage(msg []byte) ([]byte, error)
+}
+
+// A Signer that needs a password before it can sign, eg a keystore file.
+type LockableSigner interface {
+	Signer
+	Locked() bool
+	Unlock(password string) error
+	// Clears the decrypted key from memory
+	Lock()
+}
+
+// Signs with a private key held in memory.
+type KeySigner struct {
+	prv  *ecdsa.PrivateKey
+	addr common.Address
+}
+
+func NewKeySigner(prv *ecdsa.PrivateKey) *KeySigner {
+	return &KeySigner{prv, crypto.PubkeyToAddress(prv.PublicKey)}
+}
+
+func (s *KeySigner) Address() common.Address {
+	return s.addr
+}
+
+func (s *KeySigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.NewLondonSigner(chainID), s.prv)
+}
+
+func (s *KeySigner) SignMessage(msg []byte) ([]byte, error) {
+	sig, err := crypto.Sign(accounts.TextHash(msg), s.prv)
+	if err != nil {
+		return nil, err
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	return sig, nil
+}
+
+// Signs with an encrypted V3 keystore file. Locked until Unlock is called.
+type KeystoreSigner struct {
+	keyJson []byte
+	addr    common.Address
+	key     *KeySigner
+}
+
+// Loads a keystore file. See LoadKeystore.
+func NewKeystoreSigner(path, account string) (*KeystoreSigner, error) {
+	keyJson, addr, err := LoadKeystore(path, account)
+	if err != nil {
+		return nil, err
+	}
+	return &KeystoreSigner{keyJson: keyJson, addr: addr}, nil
+}
+
+func (s *KeystoreSigner) Address() common.Address {
+	return s.addr
+}
+
+func (s *KeystoreSigner) Locked() bool {
+	return s.key == nil
+}
+
+func (s *KeystoreSigner) Unlock(password string) error {
+	key, err := keystore.DecryptKey(s.keyJson, password)
+	if err != nil {
+		return err
+	}
+	// The file's address field isn't covered by the MAC
+	if key.Address != s.addr {
+		key.PrivateKey.D.SetInt64(0)
+		return fmt.Errorf("keystore decrypts to %s, not %s", key.Address, s.addr)
+	}
+	s.key = NewKeySigner(key.PrivateKey)
+	return nil
+}
+
+func (s *KeystoreSigner) Lock() {
+	if s.key == nil {
+		return
+	}
+	s.key.prv.D.SetInt64(0)
+	s.key = nil
+}
+
+func (s *KeystoreSigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	if s.key == nil {
+		return nil, fmt.Errorf("account %s locked", s.addr)
+	}
+	return s.key.SignTx(tx, chainID)
+}
+
+func (s *KeystoreSigner) SignMessage(msg []byte) ([]byte, error) {
+	if s.key == nil {
+		return nil, fmt.Errorf("account %s locked", s.addr)
+	}
+	return s.key.SignMessage(msg)
+}
+
+// Signs via an external signer speaking the Clef JSON-RPC API. Keys never
+// enter the ethcli process; the external signer asks the user to approve.
+type ClefSigner struct {
+	rpc  *rpc.Client
+	addr common.Address
+}
+
+// Clef waits for the user to approve each request.
+const clefTimeout = 5 * time.Minute
+
+// Connects to a Clef-compatible signer, eg http://localhost:8550. If account
+// is empty, uses the first account the signer lists.
+func NewClefSigner(url, account string) (*ClefSigner, error) {
+	if account != "" && !common.IsHexAddress(account) {
+		return nil, fmt.Errorf("invalid account address %s", account)
+	}
+	client, err := rpc.Dial(url)
+	if err != nil {
+		return nil, err
+	}
+	s := &ClefSigner{rpc: client}
+	if account != "" {
+		s.addr = common.HexToAddress(account)
+		return s, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), clefTimeout)
+	defer cancel()
+	var addrs []common.Address
+	if err := client.CallContext(ctx, &addrs, "account_list"); err != nil {
+		return nil, fmt.Errorf("account_list %s", err)
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("external signer has no accounts")
+	}
+	s.addr = addrs[0]
+	return s, nil
+}
+
+func (s *ClefSigner) Address() common.Address {
+	return s.addr
+}
+
+// Transaction arguments for account_signTransaction
+type clefTxArgs struct {
+	From                 common.Address    `json:"from"`
+	To                   *common.Address   `json:"to"`
+	Gas                  hexutil.Uint64    `json:"gas"`
+	GasPrice             *hexutil.Big      `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *hexutil.Big      `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big      `json:"maxPriorityFeePerGas,omitempty"`
+	Value                hexutil.Big       `json:"value"`
+	Nonce                hexutil.Uint64    `json:"nonce"`
+	Data                 hexutil.Bytes     `json:"data"`
+	ChainID              *hexutil.Big      `json:"chainId,omitempty"`
+	AccessList           *types.AccessList `json:"accessList,omitempty"`
+}
+
+func (s *ClefSigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	args := clefTxArgs{
+		From:    s.addr,
+		Gas:     hexutil.Uint64(tx.Gas()),
+		Value:   hexutil.Big(*tx.Value()),
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		Data:    tx.Data(),
+		ChainID: (*hexutil.Big)(chainID),
+	}
+	args.To = tx.To()
+	if al := tx.AccessList(); len(al) > 0 {
+		args.AccessList = &al
+	}
+	if tx.Type() != types.DynamicFeeTxType {
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	} else {
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), clefTimeout)
+	defer cancel()
+	var res struct {
+		Raw hexutil.Bytes `json:"raw"`
+	}
+	if err := s.rpc.CallContext(ctx, &res, "account_signTransaction", args); err != nil {
+		return nil, fmt.Errorf("external signer: %s", err)
+	}
+
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(res.Raw); err != nil {
+		return nil, err
+	}
+	if err := checkSignedTx(tx, signed, s.addr, chainID); err != nil {
+		return nil, err
+	}
+	return signed, nil
+}
+
+func (s *ClefSigner) SignMessage(msg []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), clefTimeout)
+	defer cancel()
+	var sig hexutil.Bytes
+	err := s.rpc.CallContext(ctx, &sig, "account_signData",
+		accounts.MimetypeTextPlain, s.addr, hexutil.Bytes(msg))
+	if err != nil {
+		return nil, fmt.Errorf("external signer: %s", err)
+	}
+	return sig, nil
+}
+
+// Don't trust the external signer blindly: make sure it signed what we asked.
+func checkSignedTx(want, got *types.Transaction, from common.Address, chainID *big.Int) error {
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), got)
+	if err != nil {
+		return err
+	}
+	if sender != from {
+		return fmt.Errorf("signed by %s, expected %s", sender, from)
+	}
+	if got.Type() != want.Type() || got.Nonce() != want.Nonce() || got.Gas() != want.Gas() ||
+		got.GasPrice().Cmp(want.GasPrice()) != 0 || got.GasFeeCap().Cmp(want.GasFeeCap()) != 0 ||
+		got.GasTipCap().Cmp(want.GasTipCap()) != 0 ||
+		got.Value().Cmp(want.Value()) != 0 || !bytes.Equal(got.Data(), want.Data()) ||
+		!addrPtrEqual(got.To(), want.To()) || !got.Protected() || got.ChainId().Cmp(chainID) != 0 ||
+		!accessListEqual(got.AccessList(), want.AccessList()) {
+		return fmt.Errorf("external signer returned a different transaction")
+	}
+	return nil
+}
+
+func accessListEqual(a, b types.AccessList) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Address != b[i].Address || len(a[i].StorageKeys) != len(b[i].StorageKeys) {
+			return false
+		}
+		for j := range a[i].StorageKeys {
+			if a[i].StorageKeys[j] != b[i].StorageKeys[j] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func addrPtrEqual(a, b *common.Address) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
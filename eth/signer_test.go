@@ -0,0 +1,196 @@
+package eth
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Fake Clef: serves the account_ namespace, signing whatever it's asked to,
+// after an optional tamper step.
+type fakeClef struct {
+	signer *KeySigner
+	tamper func(*types.DynamicFeeTx)
+}
+
+func (c *fakeClef) List() []common.Address {
+	return []common.Address{c.signer.Address()}
+}
+
+func (c *fakeClef) SignTransaction(args clefTxArgs) (map[string]hexutil.Bytes, error) {
+	inner := &types.DynamicFeeTx{
+		ChainID:   args.ChainID.ToInt(),
+		Nonce:     uint64(args.Nonce),
+		GasTipCap: args.MaxPriorityFeePerGas.ToInt(),
+		GasFeeCap: args.MaxFeePerGas.ToInt(),
+		Gas:       uint64(args.Gas),
+		To:        args.To,
+		Value:     args.Value.ToInt(),
+		Data:      args.Data,
+	}
+	if args.AccessList != nil {
+		inner.AccessList = *args.AccessList
+	}
+	if c.tamper != nil {
+		c.tamper(inner)
+	}
+	signed, err := c.signer.SignTx(types.NewTx(inner), inner.ChainID)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]hexutil.Bytes{"raw": raw}, nil
+}
+
+func startFakeClef(t *testing.T, clef *fakeClef) string {
+	server := rpc.NewServer()
+	if err := server.RegisterName("account", clef); err != nil {
+		t.Fatal(err)
+	}
+	hs := httptest.NewServer(server)
+	t.Cleanup(func() {
+		hs.Close()
+		server.Stop()
+	})
+	return hs.URL
+}
+
+func testTx() *types.Transaction {
+	to := common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   big.NewInt(1),
+		Nonce:     7,
+		GasTipCap: big.NewInt(1e9),
+		GasFeeCap: big.NewInt(30e9),
+		Gas:       100000,
+		To:        &to,
+		Value:     big.NewInt(1e18),
+		Data:      []byte{1, 2, 3},
+		AccessList: types.AccessList{{
+			Address:     to,
+			StorageKeys: []common.Hash{common.HexToHash("0x01")},
+		}},
+	})
+}
+
+func TestClefSigner(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	clef := &fakeClef{signer: NewKeySigner(key)}
+	url := startFakeClef(t, clef)
+
+	s, err := NewClefSigner(url, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Address() != clef.signer.Address() {
+		t.Fatalf("got account %s, want %s", s.Address(), clef.signer.Address())
+	}
+
+	tx := testTx()
+	signed, err := s.SignTx(tx, big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender, err := types.Sender(types.LatestSignerForChainID(big.NewInt(1)), signed)
+	if err != nil || sender != s.Address() {
+		t.Fatalf("got sender %s %v, want %s", sender, err, s.Address())
+	}
+	if signed.Hash() == tx.Hash() || signed.GasFeeCap().Cmp(tx.GasFeeCap()) != 0 {
+		t.Fatalf("unexpected signed tx %v", signed)
+	}
+}
+
+func TestClefSignerTampered(t *testing.T) {
+	tampers := map[string]func(*types.DynamicFeeTx){
+		"fee cap": func(tx *types.DynamicFeeTx) { tx.GasFeeCap = big.NewInt(900e9) },
+		"tip cap": func(tx *types.DynamicFeeTx) { tx.GasTipCap = big.NewInt(900e9) },
+		"value":   func(tx *types.DynamicFeeTx) { tx.Value = big.NewInt(2e18) },
+		"recipient": func(tx *types.DynamicFeeTx) {
+			to := common.HexToAddress("0x1111111111111111111111111111111111111111")
+			tx.To = &to
+		},
+		"access list": func(tx *types.DynamicFeeTx) { tx.AccessList = nil },
+	}
+	for name, tamper := range tampers {
+		key, _ := crypto.GenerateKey()
+		clef := &fakeClef{signer: NewKeySigner(key), tamper: tamper}
+		s, err := NewClefSigner(startFakeClef(t, clef), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = s.SignTx(testTx(), big.NewInt(1))
+		if err == nil || !strings.Contains(err.Error(), "different transaction") {
+			t.Errorf("%s: got %v, want a different transaction error", name, err)
+		}
+	}
+}
+
+// Writes a keystore file for key, with its address field replaced by addr.
+func writeTestKeystore(t *testing.T, key *keystore.Key, addr common.Address) string {
+	keyJson, err := keystore.EncryptKey(key, "hunter2", keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(keyJson, &fields); err != nil {
+		t.Fatal(err)
+	}
+	fields["address"] = strings.ToLower(addr.Hex()[2:])
+	if keyJson, err = json.Marshal(fields); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "key.json")
+	if err := os.WriteFile(path, keyJson, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestKeystoreSignerUnlock(t *testing.T) {
+	prv, _ := crypto.GenerateKey()
+	key := &keystore.Key{Address: crypto.PubkeyToAddress(prv.PublicKey), PrivateKey: prv}
+
+	s, err := NewKeystoreSigner(writeTestKeystore(t, key, key.Address), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Unlock("wrong"); err == nil || !s.Locked() {
+		t.Fatal("unlocked with the wrong password")
+	}
+	if err := s.Unlock("hunter2"); err != nil || s.Locked() {
+		t.Fatalf("unlock failed: %v", err)
+	}
+}
+
+// The address field isn't authenticated, so it must match the decrypted key
+func TestKeystoreSignerWrongAddress(t *testing.T) {
+	prv, _ := crypto.GenerateKey()
+	key := &keystore.Key{Address: crypto.PubkeyToAddress(prv.PublicKey), PrivateKey: prv}
+	other := common.HexToAddress("0x1111111111111111111111111111111111111111")
+
+	s, err := NewKeystoreSigner(writeTestKeystore(t, key, other), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Address() != other {
+		t.Fatalf("got address %s, want %s from the file", s.Address(), other)
+	}
+	err = s.Unlock("hunter2")
+	if err == nil || !strings.Contains(err.Error(), "keystore decrypts to") || !s.Locked() {
+		t.Fatalf("got %v, locked %v; want a mismatch error", err, s.Locked())
+	}
+}
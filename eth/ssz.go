@@ -0,0 +1,134 @@
+package eth
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Just enough SSZ hash_tree_root for the light client: beacon block headers,
+// execution payload headers, and sync committees.
+
+func sszHashPair(a, b common.Hash) common.Hash {
+	return sha256.Sum256(append(a[:], b[:]...))
+}
+
+// Merkleizes chunks, padded with zero chunks to the next power of two.
+func sszMerkleize(chunks []common.Hash) common.Hash {
+	if len(chunks) == 0 {
+		return common.Hash{}
+	}
+	layer := append([]common.Hash{}, chunks...)
+	// Root of an all-zero subtree at the current level
+	var zero common.Hash
+	for len(layer) > 1 {
+		if len(layer)%2 == 1 {
+			layer = append(layer, zero)
+		}
+		next := make([]common.Hash, len(layer)/2)
+		for i := range next {
+			next[i] = sszHashPair(layer[2*i], layer[2*i+1])
+		}
+		layer = next
+		zero = sszHashPair(zero, zero)
+	}
+	return layer[0]
+}
+
+// Checks a Merkle branch, leaf to root, for the leaf at index within a
+// subtree of the given depth.
+func sszVerifyBranch(leaf common.Hash, branch []common.Hash, depth int, index uint64, root common.Hash) bool {
+	if len(branch) != depth {
+		return false
+	}
+	h := leaf
+	for i, sibling := range branch {
+		if (index>>uint(i))&1 == 1 {
+			h = sszHashPair(sibling, h)
+		} else {
+			h = sszHashPair(h, sibling)
+		}
+	}
+	return h == root
+}
+
+func sszUint64(v uint64) (ret common.Hash) {
+	binary.LittleEndian.PutUint64(ret[:], v)
+	return
+}
+
+func sszUint256(v *big.Int) (ret common.Hash) {
+	b := v.Bytes()
+	for i := range b {
+		ret[i] = b[len(b)-1-i]
+	}
+	return
+}
+
+// Packs bytes into zero-padded 32-byte chunks.
+func sszPack(b []byte) []common.Hash {
+	ret := make([]common.Hash, (len(b)+31)/32)
+	for i := range ret {
+		copy(ret[i][:], b[32*i:])
+	}
+	return ret
+}
+
+// Byte list of at most 32 bytes, eg extra_data
+func sszShortByteList(b []byte) common.Hash {
+	var chunk common.Hash
+	copy(chunk[:], b)
+	return sszHashPair(chunk, sszUint64(uint64(len(b))))
+}
+
+// BLS public key: 48 bytes, so two chunks
+func sszPubkey(pk []byte) common.Hash {
+	return sszMerkleize(sszPack(pk))
+}
+
+func (h *beaconHeader) hashTreeRoot() common.Hash {
+	return sszMerkleize([]common.Hash{
+		sszUint64(uint64(h.Slot)),
+		sszUint64(uint64(h.ProposerIndex)),
+		h.ParentRoot,
+		h.StateRoot,
+		h.BodyRoot,
+	})
+}
+
+// Deneb added the blob gas fields. Capella headers lack them.
+func (h *executionHeader) hashTreeRoot(deneb bool) common.Hash {
+	var feeRecipient common.Hash
+	copy(feeRecipient[:], h.FeeRecipient[:])
+	fields := []common.Hash{
+		h.ParentHash,
+		feeRecipient,
+		h.StateRoot,
+		h.ReceiptsRoot,
+		sszMerkleize(sszPack(h.LogsBloom)),
+		h.PrevRandao,
+		sszUint64(uint64(h.BlockNumber)),
+		sszUint64(uint64(h.GasLimit)),
+		sszUint64(uint64(h.GasUsed)),
+		sszUint64(uint64(h.Timestamp)),
+		sszShortByteList(h.ExtraData),
+		sszUint256((*big.Int)(&h.BaseFeePerGas)),
+		h.BlockHash,
+		h.TransactionsRoot,
+		h.WithdrawalsRoot,
+	}
+	if deneb {
+		fields = append(fields, sszUint64(uint64(h.BlobGasUsed)), sszUint64(uint64(h.ExcessBlobGas)))
+	}
+	return sszMerkleize(fields)
+}
+
+func (s *syncCommitteeJson) hashTreeRoot() common.Hash {
+	keys := make([]common.Hash, len(s.Pubkeys))
+	for i, pk := range s.Pubkeys {
+		keys[i] = sszPubkey(pk)
+	}
+	return sszHashPair(sszMerkleize(keys), sszPubkey(s.AggregatePubkey))
+}
@@ -0,0 +1,89 @@
+package eth
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const abiERC20ViewJson = `[{"inputs":[{"name":"owner","type":"address"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},{"inputs":[],"name":"decimals","outputs":[{"name":"","type":"uint8"}],"stateMutability":"view","type":"function"},{"inputs":[],"name":"symbol","outputs":[{"name":"","type":"string"}],"stateMutability":"view","type":"function"}]`
+
+// Some older tokens, eg MKR, return symbol() as bytes32.
+const abiSymbolBytes32Json = `[{"inputs":[],"name":"symbol","outputs":[{"name":"","type":"bytes32"}],"stateMutability":"view","type":"function"}]`
+
+var (
+	abiERC20View       = parseAbi(abiERC20ViewJson)
+	abiSymbolBytes32   = parseAbi(abiSymbolBytes32Json)
+	errNotTokenAddress = fmt.Errorf("not an ERC-20 token")
+)
+
+// ERC-20 token metadata
+type TokenInfo struct {
+	Addr     common.Address
+	Symbol   string
+	Decimals uint8
+}
+
+// Fetches symbol and decimals for an ERC-20 token. Cached, since they don't change.
+func (c *Client) TokenInfo(ctx context.Context, token common.Address) (*TokenInfo, error) {
+	key := "token:" + token.Hex()
+	if v, ok := c.cache.get(key); ok {
+		return v.(*TokenInfo), nil
+	}
+	ret, err := c.tokenInfo(ctx, token)
+	if err == nil {
+		c.cache.put(key, ret, 0, 0)
+	}
+	return ret, err
+}
+
+func (c *Client) tokenInfo(ctx context.Context, token common.Address) (*TokenInfo, error) {
+	ret := &TokenInfo{Addr: token}
+
+	out, err := c.callView(ctx, token, "decimals")
+	if err != nil {
+		return nil, fmt.Errorf("%s decimals: %s", token, err)
+	}
+	if err := abiERC20View.UnpackIntoInterface(&ret.Decimals, "decimals", out); err != nil {
+		return nil, fmt.Errorf("%s decimals: %w", token, errNotTokenAddress)
+	}
+
+	out, err = c.callView(ctx, token, "symbol")
+	if err != nil {
+		return nil, fmt.Errorf("%s symbol: %s", token, err)
+	}
+	if abiERC20View.UnpackIntoInterface(&ret.Symbol, "symbol", out) != nil {
+		var sym [32]byte
+		if err := abiSymbolBytes32.UnpackIntoInterface(&sym, "symbol", out); err != nil {
+			return nil, fmt.Errorf("%s symbol: %w", token, errNotTokenAddress)
+		}
+		ret.Symbol = string(bytes.TrimRight(sym[:], "\x00"))
+	}
+	return ret, nil
+}
+
+// Returns an account's ERC-20 token balance, in base units.
+func (c *Client) TokenBalance(ctx context.Context, token, owner common.Address) (*big.Int, error) {
+	out, err := c.callView(ctx, token, "balanceOf", owner)
+	if err != nil {
+		return nil, err
+	}
+	var bal *big.Int
+	if err := abiERC20View.UnpackIntoInterface(&bal, "balanceOf", out); err != nil {
+		return nil, err
+	}
+	return bal, nil
+}
+
+func (c *Client) callView(ctx context.Context, contract common.Address, method string, args ...interface{}) ([]byte, error) {
+	data, err := abiERC20View.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	out, err := c.Ec.CallContract(ctx, ethereum.CallMsg{To: &contract, Data: data}, nil)
+	return out, RevertError(err)
+}
@@ -15,6 +15,7 @@ var (
 	TypeInDropdown = binary.BigEndian.Uint64(crypto.Keccak256([]byte("dropdown"))[24:])
 	TypeInTextbox  = binary.BigEndian.Uint64(crypto.Keccak256([]byte("textbox"))[24:])
 	TypeButton     = binary.BigEndian.Uint64(crypto.Keccak256([]byte("button"))[24:])
+	TypeLink       = binary.BigEndian.Uint64(crypto.Keccak256([]byte("link"))[24:])
 )
 
 type ElemTs []abi.ArgumentMarshaling
@@ -25,6 +26,8 @@ var (
 	PropsDropOpt  = ElemTs{{Name: "val", Type: "uint256"}, {Name: "text", Type: "string"}}
 	PropsDropdown = ElemTs{{Name: "key", Type: "uint256"}, {Name: "label", Type: "string"}, {Name: "options", Type: "tuple[]", Components: PropsDropOpt}}
 	PropsButton   = ElemTs{{Name: "key", Type: "uint256"}, {Name: "text", Type: "string"}}
+	PropsLink     = ElemTs{{Name: "key", Type: "uint256"}, {Name: "text", Type: "string"}, {Name: "target", Type: "string"}}
+	PropsTextbox  = ElemTs{{Name: "key", Type: "uint256"}, {Name: "label", Type: "string"}, {Name: "placeholder", Type: "string"}, {Name: "maxLength", Type: "uint64"}}
 )
 
 func ParseTuple(bytes []byte, elems ElemTs, ret interface{}) error {
@@ -109,6 +112,19 @@ type DropOption struct {
 	Text string
 }
 
+type ElemTextbox struct {
+	elem
+	Label string
+	// Shown greyed out while the textbox is empty.
+	Placeholder string
+	// Max length in characters. 0 means no limit.
+	MaxLength uint64
+}
+
+func (e *ElemTextbox) GetKey() uint8 {
+	return e.Key
+}
+
 type ElemButton struct {
 	elem
 	// Button label
@@ -119,6 +135,18 @@ func (e *ElemButton) GetKey() uint8 {
 	return e.Key
 }
 
+type ElemLink struct {
+	elem
+	// Link text
+	Text string
+	// Frontend contract to navigate to, eg "0x1234..." or "unicli.eth"
+	Target string
+}
+
+func (e *ElemLink) GetKey() uint8 {
+	return e.Key
+}
+
 type ButtonAction struct {
 	// Which button was pressed.
 	ButtonKey uint8
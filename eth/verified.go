@@ -0,0 +1,252 @@
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Supplies the block header that verified calls are checked against.
+type HeaderSource interface {
+	// Latest trusted execution block header. It may lack fields that
+	// go-ethereum's types.Header predates, so don't rely on its Hash().
+	TrustedHead(ctx context.Context) (*types.Header, error)
+	// Number and hash of the latest block verified independently of the
+	// RPC. Fails if there's none, eg the light client isn't synced.
+	TrustedHash() (uint64, common.Hash, error)
+}
+
+// Trusts whatever header the RPC returns. Proofs then still rule out state
+// that's inconsistent with the header, but not a fabricated header.
+type rpcHeaderSource struct {
+	ec interface {
+		HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	}
+}
+
+func (h *rpcHeaderSource) TrustedHead(ctx context.Context) (*types.Header, error) {
+	return h.ec.HeaderByNumber(ctx, nil)
+}
+
+func (h *rpcHeaderSource) TrustedHash() (uint64, common.Hash, error) {
+	return 0, common.Hash{}, fmt.Errorf("RPC headers are not verified")
+}
+
+// Runs a read-only call in a local EVM, against state proven via
+// eth_getProof to match the trusted header's state root. A malicious RPC
+// can withhold data, but not change the result.
+func (c *Client) VerifiedCall(ctx context.Context, msg ethereum.CallMsg) ([]byte, error) {
+	if msg.To == nil {
+		return nil, fmt.Errorf("verified call needs a target")
+	}
+	header, err := c.Headers.TrustedHead(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("trusted head: %s", err)
+	}
+	chainID, err := c.chainID()
+	if err != nil {
+		return nil, err
+	}
+
+	statedb := newProofState(ctx, c, header)
+	blockCtx := vm.BlockContext{
+		CanTransfer: canTransfer,
+		Transfer:    transfer,
+		GetHash:     c.ancestorHashes(ctx, header, statedb.fail),
+		Coinbase:    header.Coinbase,
+		GasLimit:    header.GasLimit,
+		BlockNumber: new(big.Int).Set(header.Number),
+		Time:        new(big.Int).SetUint64(header.Time),
+		Difficulty:  header.Difficulty,
+		BaseFee:     header.BaseFee,
+	}
+	if header.Difficulty.Sign() == 0 {
+		// Post-merge, DIFFICULTY returns PREVRANDAO
+		random := header.MixDigest
+		blockCtx.Random = &random
+	}
+	txCtx := vm.TxContext{Origin: msg.From, GasPrice: new(big.Int)}
+	config := chainConfig(chainID)
+	var vmConfig vm.Config
+	if blockCtx.Random != nil {
+		// PUSH0, from Shanghai, which our go-ethereum version predates
+		vmConfig.ExtraEips = []int{3855}
+	}
+	evm := vm.NewEVM(blockCtx, txCtx, statedb, config, vmConfig)
+
+	rules := config.Rules(blockCtx.BlockNumber, blockCtx.Random != nil)
+	statedb.PrepareAccessList(msg.From, msg.To, vm.ActivePrecompiles(rules), nil)
+
+	gas := msg.Gas
+	if gas == 0 {
+		gas = header.GasLimit
+	}
+	value := msg.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	ret, _, err := evm.Call(vm.AccountRef(msg.From), *msg.To, msg.Data, gas, value)
+	if statedb.err != nil {
+		// A failed fetch or proof makes the result meaningless, even if the
+		// call happened to succeed.
+		return nil, fmt.Errorf("verification failed at block %d: %s", header.Number, statedb.err)
+	}
+	var opErr *vm.ErrInvalidOpCode
+	if err == vm.ErrExecutionReverted {
+		return nil, fmt.Errorf("execution reverted: %s", DecodeRevert(ret))
+	} else if errors.As(err, &opErr) && !strings.HasSuffix(err.Error(), vm.INVALID.String()) {
+		// An opcode from a fork newer than our go-ethereum, eg TSTORE. A
+		// node would run it, so don't report it like a contract bug.
+		op := strings.TrimPrefix(err.Error(), "invalid opcode: ")
+		return nil, fmt.Errorf("unsupported opcode in verified mode: %s", op)
+	} else if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// Returns BLOCKHASH lookups, walking back from the trusted header via parent
+// hashes so that each ancestor is verified too. The EVM only asks for
+// earlier blocks, so the walk starts from the head's trusted parent hash.
+// If an ancestor can't be fetched or doesn't match, fail is called, which
+// fails the verified call.
+func (c *Client) ancestorHashes(ctx context.Context, head *types.Header, fail func(error)) vm.GetHashFunc {
+	known := make(map[uint64]common.Hash)
+	// Oldest verified block so far, and its parent hash
+	oldestNum, parentHash := head.Number.Uint64(), head.ParentHash
+	failed := false
+	return func(n uint64) common.Hash {
+		for !failed && oldestNum > n {
+			parent, err := c.headerByHash(ctx, parentHash)
+			if err != nil {
+				fail(fmt.Errorf("BLOCKHASH %d: %s", n, err))
+				failed = true
+				break
+			}
+			oldestNum--
+			known[oldestNum] = parentHash
+			parentHash = parent.ParentHash
+		}
+		return known[n]
+	}
+}
+
+// Fetches a header and checks that it hashes to the requested hash.
+func (c *Client) headerByHash(ctx context.Context, hash common.Hash) (*rpcHeader, error) {
+	var raw json.RawMessage
+	if err := c.Rpc.CallContext(ctx, &raw, "eth_getBlockByHash", hash, false); err != nil {
+		return nil, err
+	} else if len(raw) == 0 || string(raw) == "null" {
+		return nil, fmt.Errorf("block %s not found", hash)
+	}
+	h, err := decodeRpcHeader(raw)
+	if err != nil {
+		return nil, err
+	}
+	if got := h.hash(); got != hash {
+		return nil, fmt.Errorf("block %s: header hashes to %s", hash, got)
+	}
+	return h, nil
+}
+
+// Execution block header as returned by the JSON-RPC API. Hashed field by
+// field, since go-ethereum's types.Header lacks fields added after our
+// version, eg withdrawalsRoot.
+type rpcHeader struct {
+	ParentHash  common.Hash      `json:"parentHash"`
+	UncleHash   common.Hash      `json:"sha3Uncles"`
+	Coinbase    common.Address   `json:"miner"`
+	Root        common.Hash      `json:"stateRoot"`
+	TxHash      common.Hash      `json:"transactionsRoot"`
+	ReceiptHash common.Hash      `json:"receiptsRoot"`
+	Bloom       hexutil.Bytes    `json:"logsBloom"`
+	Difficulty  *hexutil.Big     `json:"difficulty"`
+	Number      *hexutil.Big     `json:"number"`
+	GasLimit    hexutil.Uint64   `json:"gasLimit"`
+	GasUsed     hexutil.Uint64   `json:"gasUsed"`
+	Time        hexutil.Uint64   `json:"timestamp"`
+	Extra       hexutil.Bytes    `json:"extraData"`
+	MixDigest   common.Hash      `json:"mixHash"`
+	Nonce       types.BlockNonce `json:"nonce"`
+	// Added by later forks, in this order
+	BaseFee          *hexutil.Big    `json:"baseFeePerGas"`
+	WithdrawalsRoot  *common.Hash    `json:"withdrawalsRoot"`
+	BlobGasUsed      *hexutil.Uint64 `json:"blobGasUsed"`
+	ExcessBlobGas    *hexutil.Uint64 `json:"excessBlobGas"`
+	ParentBeaconRoot *common.Hash    `json:"parentBeaconBlockRoot"`
+	RequestsHash     *common.Hash    `json:"requestsHash"`
+}
+
+func decodeRpcHeader(raw json.RawMessage) (*rpcHeader, error) {
+	var h rpcHeader
+	if err := json.Unmarshal(raw, &h); err != nil {
+		return nil, fmt.Errorf("invalid header: %s", err)
+	}
+	if h.Difficulty == nil || h.Number == nil {
+		return nil, fmt.Errorf("invalid header: missing fields")
+	}
+	return &h, nil
+}
+
+// Keccak of the RLP header. Each fork since London appended fields, which
+// are present from that fork on.
+func (h *rpcHeader) hash() common.Hash {
+	fields := []interface{}{
+		h.ParentHash, h.UncleHash, h.Coinbase, h.Root, h.TxHash, h.ReceiptHash,
+		[]byte(h.Bloom), h.Difficulty.ToInt(), h.Number.ToInt(), uint64(h.GasLimit),
+		uint64(h.GasUsed), uint64(h.Time), []byte(h.Extra), h.MixDigest, h.Nonce,
+	}
+	if h.BaseFee != nil {
+		fields = append(fields, h.BaseFee.ToInt())
+	}
+	if h.WithdrawalsRoot != nil {
+		fields = append(fields, *h.WithdrawalsRoot)
+	}
+	if h.BlobGasUsed != nil && h.ExcessBlobGas != nil {
+		fields = append(fields, uint64(*h.BlobGasUsed), uint64(*h.ExcessBlobGas))
+	}
+	if h.ParentBeaconRoot != nil {
+		fields = append(fields, *h.ParentBeaconRoot)
+	}
+	if h.RequestsHash != nil {
+		fields = append(fields, *h.RequestsHash)
+	}
+	enc, _ := rlp.EncodeToBytes(fields)
+	return crypto.Keccak256Hash(enc)
+}
+
+// Chain rules for the EVM. Unknown chains, eg a local devnet, get all forks.
+func chainConfig(chainID *big.Int) *params.ChainConfig {
+	switch chainID.Uint64() {
+	case 1:
+		return params.MainnetChainConfig
+	case 5:
+		return params.GoerliChainConfig
+	case 11155111:
+		return params.SepoliaChainConfig
+	}
+	config := *params.AllEthashProtocolChanges
+	config.ChainID = chainID
+	return &config
+}
+
+func canTransfer(db vm.StateDB, addr common.Address, amount *big.Int) bool {
+	return db.GetBalance(addr).Cmp(amount) >= 0
+}
+
+func transfer(db vm.StateDB, sender, recipient common.Address, amount *big.Int) {
+	db.SubBalance(sender, amount)
+	db.AddBalance(recipient, amount)
+}
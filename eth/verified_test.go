@@ -0,0 +1,248 @@
+package eth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Test contracts, hand-assembled. Each returns one word.
+var (
+	// Returns SLOAD(calldata[0:32])
+	slotReader    = common.HexToAddress("0x1000")
+	slotReaderBin = common.FromHex("6000355460005260206000f3")
+	// Returns BLOCKHASH(NUMBER - calldata[0:32])
+	hashReader    = common.HexToAddress("0x2000")
+	hashReaderBin = common.FromHex("60003543034060005260206000f3")
+	// CREATEs a child whose init code does SSTORE(0, 1), returns its address
+	creator    = common.HexToAddress("0x3000")
+	creatorBin = common.FromHex("656001600055006000526006601a6000f060005260206000f3")
+	// TLOAD(0), from Cancun, which our go-ethereum predates
+	tloader    = common.HexToAddress("0x4000")
+	tloaderBin = common.FromHex("60005c")
+	// INVALID, which fails on any fork
+	invalid    = common.HexToAddress("0x5000")
+	invalidBin = common.FromHex("fe")
+)
+
+// Simulated chain with the test contracts and a few blocks, served over
+// an in-process RPC with just the methods a verified call needs.
+func newTestChain(t *testing.T) (*Client, *backends.SimulatedBackend) {
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{
+		slotReader: {
+			Code:    slotReaderBin,
+			Balance: new(big.Int),
+			Storage: map[common.Hash]common.Hash{
+				common.HexToHash("0x00"): common.HexToHash("0x2a"),
+				common.HexToHash("0x07"): common.HexToHash("0xbeef"),
+			},
+		},
+		hashReader: {Code: hashReaderBin, Balance: new(big.Int)},
+		creator:    {Code: creatorBin, Balance: new(big.Int), Nonce: 1},
+		tloader:    {Code: tloaderBin, Balance: new(big.Int)},
+		invalid:    {Code: invalidBin, Balance: new(big.Int)},
+	}, 30000000)
+	t.Cleanup(func() { sim.Close() })
+	for i := 0; i < 8; i++ {
+		sim.Commit()
+	}
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", &testEthApi{sim.Blockchain()}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Stop)
+	rpcClient := rpc.DialInProc(server)
+	ec := ethclient.NewClient(rpcClient)
+	c := &Client{
+		Ec:      ec,
+		Rpc:     rpcClient,
+		Headers: &rpcHeaderSource{sim},
+		cache:   newLruCache(cacheMaxItems),
+	}
+	return c, sim
+}
+
+// Serves the eth_ methods used by VerifiedCall from a local chain.
+type testEthApi struct {
+	chain *core.BlockChain
+}
+
+func (api *testEthApi) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(api.chain.Config().ChainID)
+}
+
+func (api *testEthApi) GetBlockByHash(hash common.Hash, full bool) *types.Header {
+	return api.chain.GetHeaderByHash(hash)
+}
+
+func (api *testEthApi) state(block hexutil.Big) (*state.StateDB, error) {
+	header := api.chain.GetHeaderByNumber(block.ToInt().Uint64())
+	if header == nil {
+		return nil, fmt.Errorf("block %s not found", block.String())
+	}
+	return api.chain.StateAt(header.Root)
+}
+
+func (api *testEthApi) GetCode(addr common.Address, block hexutil.Big) (hexutil.Bytes, error) {
+	statedb, err := api.state(block)
+	if err != nil {
+		return nil, err
+	}
+	return statedb.GetCode(addr), nil
+}
+
+func (api *testEthApi) GetProof(addr common.Address, keys []common.Hash, block hexutil.Big) (*testProofResult, error) {
+	statedb, err := api.state(block)
+	if err != nil {
+		return nil, err
+	}
+	var res testProofResult
+	proof, err := statedb.GetProof(addr)
+	if err != nil {
+		return nil, err
+	}
+	res.AccountProof = toHexProof(proof)
+	for _, key := range keys {
+		proof, err := statedb.GetStorageProof(addr, key)
+		if err != nil {
+			return nil, err
+		}
+		res.StorageProof = append(res.StorageProof, testStorageProof{key.Bytes(), toHexProof(proof)})
+	}
+	return &res, nil
+}
+
+type testProofResult struct {
+	AccountProof []hexutil.Bytes    `json:"accountProof"`
+	StorageProof []testStorageProof `json:"storageProof"`
+}
+
+type testStorageProof struct {
+	Key   hexutil.Bytes   `json:"key"`
+	Proof []hexutil.Bytes `json:"proof"`
+}
+
+func toHexProof(proof [][]byte) []hexutil.Bytes {
+	ret := make([]hexutil.Bytes, len(proof))
+	for i, node := range proof {
+		ret[i] = node
+	}
+	return ret
+}
+
+func TestVerifiedCall(t *testing.T) {
+	c, sim := newTestChain(t)
+	ctx := context.Background()
+	word := func(v int64) []byte {
+		return common.BigToHash(big.NewInt(v)).Bytes()
+	}
+	tests := []struct {
+		name string
+		to   common.Address
+		data []byte
+	}{
+		{"storage slot", slotReader, word(0)},
+		{"other slot", slotReader, word(7)},
+		{"empty slot", slotReader, word(3)},
+		{"parent hash", hashReader, word(1)},
+		{"ancestor hash", hashReader, word(5)},
+		{"create", creator, nil},
+	}
+	for _, tt := range tests {
+		msg := ethereum.CallMsg{To: &tt.to, Data: tt.data, Gas: 1000000}
+		want, err := sim.CallContract(ctx, msg, nil)
+		if err != nil {
+			t.Fatalf("%s: eth_call %s", tt.name, err)
+		}
+		got, err := c.VerifiedCall(ctx, msg)
+		if err != nil {
+			t.Fatalf("%s: %s", tt.name, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: verified %x, eth_call %x", tt.name, got, want)
+		}
+		if bytes.Equal(want, make([]byte, 32)) && tt.name != "empty slot" {
+			t.Errorf("%s: expected a nonzero result", tt.name)
+		}
+	}
+}
+
+// Fails rather than returning zero when an ancestor can't be verified.
+func TestVerifiedCallBadAncestor(t *testing.T) {
+	c, _ := newTestChain(t)
+	ctx := context.Background()
+	head, err := c.Headers.TrustedHead(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head.ParentHash = common.HexToHash("0x1234")
+	c.Headers = &fixedHeaderSource{head}
+
+	msg := ethereum.CallMsg{To: &hashReader, Data: common.BigToHash(big.NewInt(2)).Bytes()}
+	ret, err := c.VerifiedCall(ctx, msg)
+	if err == nil || !strings.Contains(err.Error(), "BLOCKHASH") {
+		t.Fatalf("got %x %v, want a BLOCKHASH error", ret, err)
+	}
+}
+
+// Reports opcodes from newer forks as unsupported, not as a failed call.
+func TestVerifiedCallUnsupportedOpcode(t *testing.T) {
+	c, _ := newTestChain(t)
+	ctx := context.Background()
+	_, err := c.VerifiedCall(ctx, ethereum.CallMsg{To: &tloader, Gas: 100000})
+	if err == nil || !strings.Contains(err.Error(), "unsupported opcode in verified mode") {
+		t.Errorf("TLOAD: got %v, want unsupported opcode", err)
+	}
+	_, err = c.VerifiedCall(ctx, ethereum.CallMsg{To: &invalid, Gas: 100000})
+	if err == nil || strings.Contains(err.Error(), "unsupported") {
+		t.Errorf("INVALID: got %v, want invalid opcode", err)
+	}
+}
+
+type fixedHeaderSource struct {
+	head *types.Header
+}
+
+func (h *fixedHeaderSource) TrustedHead(ctx context.Context) (*types.Header, error) {
+	return h.head, nil
+}
+
+func (h *fixedHeaderSource) TrustedHash() (uint64, common.Hash, error) {
+	return 0, common.Hash{}, fmt.Errorf("not verified")
+}
+
+// Headers from later forks, encoded and hashed by go-ethereum v1.15
+func TestRpcHeaderHash(t *testing.T) {
+	shanghai := `{"parentHash":"0x00000000000000000000000000000000000000000000000000000000000000aa","sha3Uncles":"0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347","miner":"0x95222290dd7278aa3ddd389cc1e1d165cc4bafe5","stateRoot":"0x00000000000000000000000000000000000000000000000000000000000000bb","transactionsRoot":"0x00000000000000000000000000000000000000000000000000000000000000cc","receiptsRoot":"0x00000000000000000000000000000000000000000000000000000000000000dd","logsBloom":"0x` + zeroBloom + `","difficulty":"0x0","number":"0x156456c","gasLimit":"0x2255100","gasUsed":"0xbc614e","timestamp":"0x681b3057","extraData":"0x6265617665726275696c642e6f7267","mixHash":"0x00000000000000000000000000000000000000000000000000000000000000ee","nonce":"0x0000000000000000","baseFeePerGas":"0x499602d2","withdrawalsRoot":"0x0000000000000000000000000000000000000000000000000000000000000011","blobGasUsed":null,"excessBlobGas":null,"parentBeaconBlockRoot":null,"requestsHash":null,"hash":"0xf21abcefbfa1df21054323c4607b0e393b6956aef05de26457c3c6578ee04161"}`
+	prague := `{"parentHash":"0x00000000000000000000000000000000000000000000000000000000000000aa","sha3Uncles":"0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347","miner":"0x95222290dd7278aa3ddd389cc1e1d165cc4bafe5","stateRoot":"0x00000000000000000000000000000000000000000000000000000000000000bb","transactionsRoot":"0x00000000000000000000000000000000000000000000000000000000000000cc","receiptsRoot":"0x00000000000000000000000000000000000000000000000000000000000000dd","logsBloom":"0x` + zeroBloom + `","difficulty":"0x0","number":"0x156456c","gasLimit":"0x2255100","gasUsed":"0xbc614e","timestamp":"0x681b3057","extraData":"0x6265617665726275696c642e6f7267","mixHash":"0x00000000000000000000000000000000000000000000000000000000000000ee","nonce":"0x0000000000000000","baseFeePerGas":"0x499602d2","withdrawalsRoot":"0x0000000000000000000000000000000000000000000000000000000000000011","blobGasUsed":"0x20000","excessBlobGas":"0x60000","parentBeaconBlockRoot":"0x0000000000000000000000000000000000000000000000000000000000000022","requestsHash":"0x0000000000000000000000000000000000000000000000000000000000000033","hash":"0xf7449aafb925d9a7e904bed615381cf530373139cc65d1ca233eaa8f54a81854"}`
+	for _, js := range []string{shanghai, prague} {
+		h, err := decodeRpcHeader(json.RawMessage(js))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var want struct {
+			Hash common.Hash `json:"hash"`
+		}
+		json.Unmarshal([]byte(js), &want)
+		if got := h.hash(); got != want.Hash {
+			t.Errorf("got %s, want %s", got, want.Hash)
+		}
+	}
+}
+
+var zeroBloom = strings.Repeat("00", 256)
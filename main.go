@@ -1,23 +1,34 @@
 package main
 
 import (
-	"crypto/ecdsa"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"dcposch.eth/cli/act"
 	"dcposch.eth/cli/eth"
 	"dcposch.eth/cli/ui"
 	"dcposch.eth/cli/util"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
 type Opts struct {
 	ethRpcUrl  string
-	privateKey *ecdsa.PrivateKey
+	accessList bool
+	verify     bool
+	beaconUrl  string
+	checkpoint common.Hash
+	abiDir     string
+	keyOpts    act.KeyOpts
+	actOpts    act.Opts
 	logFile    string
+	// Subcommand and its args, eg ["broadcast", "tx.hex"]. Empty for the browser.
+	command []string
 }
 
 func main() {
@@ -26,9 +37,25 @@ func main() {
 
 	// Connect to Ethereum
 	client := eth.CreateClient(opts.ethRpcUrl)
+	client.UseAccessList = opts.accessList
+	client.Verify = opts.verify
+	if opts.beaconUrl != "" {
+		lc, err := eth.NewLightClient(opts.beaconUrl, opts.checkpoint, client.ConnStatus().ChainID)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(2)
+		}
+		lc.Start()
+		client.Headers = lc
+		client.Verify = true
+	}
+
+	if len(opts.command) > 0 {
+		os.Exit(runCommand(client, opts.command))
+	}
 
 	// Initialize browser state. One-way data flow: action > state > render.
-	act.Init(client, opts.privateKey, ui.Render)
+	act.Init(client, opts.keyOpts, opts.actOpts, ui.Render)
 
 	// Show a terminal dapp browser
 	ui.StartRenderer()
@@ -36,9 +63,31 @@ func main() {
 
 // Returns either valid options or exits printing an error message.
 func parseArgsOrExit() (r Opts) {
-	flag.StringVar(&r.ethRpcUrl, "rpc-url", os.Getenv("ETH_RPC_URL"), "[env ETH_RPC_URL]")
+	flag.StringVar(&r.ethRpcUrl, "rpc-url", os.Getenv("ETH_RPC_URL"), "HTTP or websocket RPC URL. Websockets get new blocks without polling. [env ETH_RPC_URL]")
 	var privateKeyHex string
-	flag.StringVar(&privateKeyHex, "private-key", "", "Account private key")
+	flag.StringVar(&privateKeyHex, "private-key", "", "Account private key. Prefer --keystore.")
+	var keystorePath, clefUrl, account string
+	flag.StringVar(&keystorePath, "keystore", "", "Encrypted JSON keystore file or directory")
+	flag.StringVar(&clefUrl, "signer", "", "External Clef-compatible signer URL, eg http://localhost:8550")
+	flag.StringVar(&account, "account", "", "Account address, if --keystore or --signer has several")
+	var mnemonicFile string
+	var hdAccounts int
+	flag.StringVar(&mnemonicFile, "mnemonic-file", "", "File containing a BIP-39 mnemonic")
+	flag.IntVar(&hdAccounts, "hd-accounts", 5, "Number of accounts to derive from --mnemonic-file")
+	flag.DurationVar(&r.keyOpts.LockAfter, "lock-after", 15*time.Minute, "Lock keystore account when idle. 0 to disable.")
+	var watchAddr string
+	flag.StringVar(&watchAddr, "from", "", "Watch-only address, for browsing and exporting unsigned transactions without a key")
+	flag.StringVar(&r.keyOpts.ExportDir, "export-dir", ".", "Directory for exported unsigned transactions")
+	flag.BoolVar(&r.accessList, "access-list", false, "Attach an EIP-2930 access list to transactions, via eth_createAccessList")
+	flag.BoolVar(&r.verify, "verify", false, "Run app render() and act() in a local EVM against state proven via eth_getProof, instead of trusting eth_call")
+	flag.StringVar(&r.beaconUrl, "beacon-url", os.Getenv("BEACON_API_URL"), "Beacon node API URL. Verifies block headers via the light client protocol, instead of trusting the RPC. Implies --verify. [env BEACON_API_URL]")
+	var checkpoint string
+	flag.StringVar(&checkpoint, "checkpoint", "", "Trusted beacon block root for --beacon-url to sync from, eg a recent finalized block")
+	flag.BoolVar(&r.actOpts.RefreshOnBlock, "refresh-on-block", false, "Auto-refresh apps on each new block. Toggle per tab with Alt+R.")
+	flag.DurationVar(&r.actOpts.RefreshEvery, "refresh-every", 0, "Auto-refresh apps on a timer instead, eg 15s")
+	var tokens string
+	flag.StringVar(&tokens, "tokens", "", "Comma-separated ERC-20 token addresses to show balances for")
+	flag.StringVar(&r.abiDir, "abi-dir", "", "Directory of contract ABI JSON files, for decoding calls and custom errors")
 	flag.StringVar(&r.logFile, "log-file", "", "Debug log file. Default: new temp file.")
 	flag.Parse()
 
@@ -48,15 +97,111 @@ func parseArgsOrExit() (r Opts) {
 		os.Exit(2)
 	}
 
+	for _, t := range strings.Split(tokens, ",") {
+		if t = strings.TrimSpace(t); t == "" {
+			continue
+		} else if !common.IsHexAddress(t) {
+			fmt.Printf("Invalid token address %s\n", t)
+			os.Exit(2)
+		}
+		r.actOpts.Tokens = append(r.actOpts.Tokens, common.HexToAddress(t))
+	}
+
+	if r.beaconUrl != "" {
+		b, err := hexutil.Decode(checkpoint)
+		if err != nil || len(b) != 32 {
+			fmt.Println("--beacon-url needs a --checkpoint block root, 0x followed by 64 hex digits")
+			os.Exit(2)
+		}
+		r.checkpoint = common.BytesToHash(b)
+	}
+
+	if r.abiDir != "" {
+		if err := eth.LoadAbiDir(r.abiDir); err != nil {
+			fmt.Println(err)
+			os.Exit(2)
+		}
+	}
+
+	r.command = flag.Args()
+	if len(r.command) > 0 && r.command[0] != "broadcast" {
+		flag.Usage()
+		fmt.Printf("Unknown command %s. Commands: broadcast <signed tx hex or file>\n", r.command[0])
+		os.Exit(2)
+	}
+
+	nKeys := 0
+	for _, v := range []string{privateKeyHex, keystorePath, clefUrl, mnemonicFile} {
+		if v != "" {
+			nKeys++
+		}
+	}
+	if nKeys > 1 {
+		fmt.Println("Pass only one of --private-key, --keystore, --signer or --mnemonic-file")
+		os.Exit(2)
+	}
+
+	if watchAddr != "" {
+		if !common.IsHexAddress(watchAddr) {
+			fmt.Println("Invalid --from address")
+			os.Exit(2)
+		}
+		if nKeys > 0 {
+			fmt.Println("--from is for watch-only mode, without a key")
+			os.Exit(2)
+		}
+		r.keyOpts.WatchAddr = common.HexToAddress(watchAddr)
+	}
+
+	if account != "" && !common.IsHexAddress(account) {
+		flag.Usage()
+		fmt.Printf("Invalid --account address %s\n", account)
+		os.Exit(2)
+	}
+
+	var err error
 	if privateKeyHex != "" {
 		privateKey, err := crypto.HexToECDSA(privateKeyHex)
 		util.Must(err)
-		r.privateKey = privateKey
+		r.keyOpts.Signer = eth.NewKeySigner(privateKey)
+	} else if keystorePath != "" {
+		r.keyOpts.Signer, err = eth.NewKeystoreSigner(keystorePath, account)
+	} else if clefUrl != "" {
+		r.keyOpts.Signer, err = eth.NewClefSigner(clefUrl, account)
+	} else if mnemonicFile != "" {
+		r.keyOpts.Accounts, err = loadMnemonic(mnemonicFile, hdAccounts)
+		if err == nil {
+			r.keyOpts.Signer = r.keyOpts.Accounts[0]
+		}
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(2)
 	}
 
 	return
 }
 
+// Derives accounts from a mnemonic file, m/44'/60'/0'/0/i for i < n.
+func loadMnemonic(path string, n int) ([]eth.Signer, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("--hd-accounts must be at least 1")
+	}
+	mnemonic, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := eth.DeriveHDKeys(string(mnemonic), "", n)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]eth.Signer, n)
+	for i, k := range keys {
+		ret[i] = eth.NewKeySigner(k)
+	}
+	return ret, nil
+}
+
 // Log to a temp file. We're about to start tview and cannot log to terminal.
 func startLogging(path string) {
 	var logFile *os.File
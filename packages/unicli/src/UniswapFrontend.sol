@@ -5,10 +5,12 @@ import "./interface/IFrontend.sol";
 import "./interface/IUniswap.sol";
 import "./interface/VElem.sol";
 
-IUniswapV2Factory constant uniFactory = IUniswapV2Factory(
+IUniswapV2Router01 constant uniRouter = IUniswapV2Router01(
     0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D
 );
 
+uint256 constant TOKEN_ETH = 1;
+
 contract UniswapFrontend is IFrontend {
     function render(bytes calldata appState)
         external
@@ -18,23 +20,107 @@ contract UniswapFrontend is IFrontend {
     {
         require(appState.length == 0, "Unexpected state");
 
-        vdom = new VElem[](5);
+        vdom = new VElem[](6);
         vdom[0] = V.Text(1, "HELLO WORLD");
         vdom[1] = V.Amount(2, "Amount in", 18);
         vdom[2] = V.Dropdown(3, "Token in", _tokens());
         vdom[3] = V.Dropdown(4, "Token out", _tokens());
-        vdom[4] = V.Button(5, "Swap");
+        vdom[4] = V.Amount(6, "Max slippage %", 2);
+        vdom[5] = V.Button(5, "Swap");
     }
 
     function _tokens() public pure returns (DropOpt[] memory ret) {
         ret = new DropOpt[](3);
-        ret[0] = DropOpt(1, "ETH");
+        ret[0] = DropOpt(TOKEN_ETH, "ETH");
         ret[1] = DropOpt(0x00f80a32a835f79d7787e8a8ee5721d0feafd78108, "DAI");
         ret[2] = DropOpt(0x00c778417e063141139fce010982780140aa0cd5ab, "WETH");
     }
 
     function act(bytes calldata appState, Action calldata action)
         external
-        returns (bytes memory newAppState)
-    {}
+        view
+        override
+        returns (bytes memory newAppState, Call[] memory calls)
+    {
+        require(action.buttonKey == 5, "Unknown button");
+        require(action.inputs[2].length > 0, "Enter an amount");
+        require(action.inputs[3].length > 0, "Pick a token in");
+        require(action.inputs[4].length > 0, "Pick a token out");
+        require(action.inputs[6].length > 0, "Enter max slippage");
+        uint256 amountIn = abi.decode(action.inputs[2], (uint256));
+        uint256 tokenIn = abi.decode(action.inputs[3], (uint256));
+        uint256 tokenOut = abi.decode(action.inputs[4], (uint256));
+        // Percent with two decimals, so basis points
+        uint256 slippageBps = abi.decode(action.inputs[6], (uint256));
+        require(amountIn > 0, "Enter an amount");
+        require(tokenIn != tokenOut, "Pick two different tokens");
+        require(slippageBps < 10000, "Slippage must be under 100%");
+
+        address[] memory path = new address[](2);
+        path[0] = _tokenAddr(tokenIn);
+        path[1] = _tokenAddr(tokenOut);
+        // Quote at the current reserves. The swap reverts if the price moves
+        // against the user by more than the slippage they entered.
+        uint256[] memory amounts = uniRouter.getAmountsOut(amountIn, path);
+        uint256 amountOutMin = (amounts[1] * (10000 - slippageBps)) / 10000;
+        uint256 deadline = block.timestamp + 20 minutes;
+
+        Call memory swap;
+        if (tokenIn == TOKEN_ETH) {
+            swap = Call(
+                address(uniRouter),
+                amountIn,
+                abi.encodeCall(
+                    uniRouter.swapExactETHForTokens,
+                    (amountOutMin, path, msg.sender, deadline)
+                )
+            );
+        } else if (tokenOut == TOKEN_ETH) {
+            swap = Call(
+                address(uniRouter),
+                0,
+                abi.encodeCall(
+                    uniRouter.swapExactTokensForETH,
+                    (amountIn, amountOutMin, path, msg.sender, deadline)
+                )
+            );
+        } else {
+            swap = Call(
+                address(uniRouter),
+                0,
+                abi.encodeCall(
+                    uniRouter.swapExactTokensForTokens,
+                    (amountIn, amountOutMin, path, msg.sender, deadline)
+                )
+            );
+        }
+
+        // The router pulls tokens in via transferFrom, so approve it first
+        // unless the user already has. The client calls act() from the
+        // user's account, so that's msg.sender.
+        IERC20 token = IERC20(path[0]);
+        if (
+            tokenIn != TOKEN_ETH &&
+            token.allowance(msg.sender, address(uniRouter)) < amountIn
+        ) {
+            calls = new Call[](2);
+            calls[0] = Call(
+                address(token),
+                0,
+                abi.encodeCall(token.approve, (address(uniRouter), amountIn))
+            );
+            calls[1] = swap;
+        } else {
+            calls = new Call[](1);
+            calls[0] = swap;
+        }
+        newAppState = appState;
+    }
+
+    function _tokenAddr(uint256 token) internal pure returns (address) {
+        if (token == TOKEN_ETH) {
+            return uniRouter.WETH();
+        }
+        return address(uint160(token));
+    }
 }
@@ -11,7 +11,7 @@ interface IFrontend {
 
     function act(bytes calldata appState, Action calldata action)
         external
-        returns (bytes memory newAppState);
+        returns (bytes memory newAppState, Call[] memory calls);
 }
 
 struct Action {
@@ -20,3 +20,12 @@ struct Action {
     /** @dev ABI serialization of each input.  */
     bytes[] inputs;
 }
+
+struct Call {
+    /** @dev Contract to call, eg the Uniswap router. */
+    address target;
+    /** @dev ETH value to send, in wei. */
+    uint256 value;
+    /** @dev ABI-encoded calldata. The user reviews and signs each call. */
+    bytes data;
+}
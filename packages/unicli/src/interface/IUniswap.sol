@@ -7,6 +7,15 @@ interface IERC20Meta {
     function decimals() external returns (uint8);
 }
 
+interface IERC20 {
+    function allowance(address owner, address spender)
+        external
+        view
+        returns (uint256);
+
+    function approve(address spender, uint256 value) external returns (bool);
+}
+
 interface IUniswapV2Factory {
     event PairCreated(
         address indexed token0,
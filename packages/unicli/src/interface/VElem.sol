@@ -6,6 +6,7 @@ uint64 constant TYPE_IN_AMOUNT = uint64(uint256(keccak256("amount")));
 uint64 constant TYPE_IN_DROPDOWN = uint64(uint256(keccak256("dropdown")));
 uint64 constant TYPE_IN_TEXTBOX = uint64(uint256(keccak256("textbox")));
 uint64 constant TYPE_BUTTON = uint64(uint256(keccak256("button")));
+uint64 constant TYPE_LINK = uint64(uint256(keccak256("link")));
 
 struct VElem {
     /** @dev Text field, input, button, etc. */
@@ -45,6 +46,19 @@ library V {
             );
     }
 
+    function Textbox(
+        uint256 key,
+        string memory label,
+        string memory placeholder,
+        uint64 maxLength
+    ) internal pure returns (VElem memory) {
+        return
+            VElem(
+                TYPE_IN_TEXTBOX,
+                abi.encode(ElemTextbox(key, label, placeholder, maxLength))
+            );
+    }
+
     function Button(uint256 key, string memory text)
         internal
         pure
@@ -52,6 +66,14 @@ library V {
     {
         return VElem(TYPE_BUTTON, abi.encode(ElemButton(key, text)));
     }
+
+    function Link(
+        uint256 key,
+        string memory text,
+        string memory target
+    ) internal pure returns (VElem memory) {
+        return VElem(TYPE_LINK, abi.encode(ElemLink(key, text, target)));
+    }
 }
 
 struct ElemText {
@@ -83,8 +105,26 @@ struct DropOpt {
     string text;
 }
 
+struct ElemTextbox {
+    uint256 key;
+    /** @dev Form input label */
+    string label;
+    /** @dev Shown while the textbox is empty. */
+    string placeholder;
+    /** @dev Max length in characters, 0 for no limit. Input is abi.encode(string). */
+    uint64 maxLength;
+}
+
 struct ElemButton {
     uint256 key;
     /** Button text */
     string text;
 }
+
+struct ElemLink {
+    uint256 key;
+    /** Link text */
+    string text;
+    /** @dev Frontend contract to navigate to. Hex address or ENS name. */
+    string target;
+}
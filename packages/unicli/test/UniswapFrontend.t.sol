@@ -6,11 +6,144 @@ import "forge-std/Test.sol";
 import "src/UniswapFrontend.sol";
 
 contract ContractTest is Test {
-    function setUp() public {}
+    address constant weth = address(0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2);
+    address constant dai = address(0xf80A32A835F79D7787E8a8ee5721D0fEaFd78108);
 
-    function testRender() public {
-        UniswapFrontend f = new UniswapFrontend();
+    UniswapFrontend f;
+
+    function setUp() public {
+        f = new UniswapFrontend();
 
+        // Stand-in router: quotes 2000 DAI for 1 ETH
+        vm.etch(address(uniRouter), hex"00");
+        vm.mockCall(
+            address(uniRouter),
+            abi.encodeWithSelector(uniRouter.WETH.selector),
+            abi.encode(weth)
+        );
+        address[] memory path = new address[](2);
+        path[0] = weth;
+        path[1] = dai;
+        uint256[] memory amounts = new uint256[](2);
+        amounts[0] = 1 ether;
+        amounts[1] = 2000 ether;
+        vm.mockCall(
+            address(uniRouter),
+            abi.encodeCall(uniRouter.getAmountsOut, (1 ether, path)),
+            abi.encode(amounts)
+        );
+    }
+
+    function testRender() public {
         f.render(hex"");
     }
+
+    function testAct() public {
+        // 0.50% slippage
+        Action memory action = _swap(1 ether, TOKEN_ETH, uint160(dai), 50);
+        (, Call[] memory calls) = f.act(hex"", action);
+
+        address[] memory path = new address[](2);
+        path[0] = weth;
+        path[1] = dai;
+        assertEq(calls.length, 1);
+        assertEq(calls[0].target, address(uniRouter));
+        assertEq(calls[0].value, 1 ether);
+        assertEq(
+            calls[0].data,
+            abi.encodeCall(
+                uniRouter.swapExactETHForTokens,
+                (1990 ether, path, address(this), block.timestamp + 20 minutes)
+            )
+        );
+    }
+
+    function testActApprovesTokenIn() public {
+        // Stand-in DAI, with no allowance yet
+        vm.etch(dai, hex"00");
+        _mockAllowance(0);
+        address[] memory path = new address[](2);
+        path[0] = dai;
+        path[1] = weth;
+        uint256[] memory amounts = new uint256[](2);
+        amounts[0] = 2000 ether;
+        amounts[1] = 1 ether;
+        vm.mockCall(
+            address(uniRouter),
+            abi.encodeCall(uniRouter.getAmountsOut, (2000 ether, path)),
+            abi.encode(amounts)
+        );
+
+        // 1% slippage
+        Action memory action = _swap(
+            2000 ether,
+            uint160(dai),
+            TOKEN_ETH,
+            100
+        );
+        (, Call[] memory calls) = f.act(hex"", action);
+        assertEq(calls.length, 2);
+        assertEq(calls[0].target, dai);
+        assertEq(calls[0].value, 0);
+        assertEq(
+            calls[0].data,
+            abi.encodeCall(IERC20.approve, (address(uniRouter), 2000 ether))
+        );
+        assertEq(calls[1].target, address(uniRouter));
+        assertEq(
+            calls[1].data,
+            abi.encodeCall(
+                uniRouter.swapExactTokensForETH,
+                (
+                    2000 ether,
+                    0.99 ether,
+                    path,
+                    address(this),
+                    block.timestamp + 20 minutes
+                )
+            )
+        );
+
+        // Already approved, so just the swap
+        _mockAllowance(2000 ether);
+        (, calls) = f.act(hex"", action);
+        assertEq(calls.length, 1);
+        assertEq(calls[0].target, address(uniRouter));
+    }
+
+    function testActRequiresSlippage() public {
+        Action memory action = _swap(1 ether, TOKEN_ETH, uint160(dai), 0);
+        action.inputs[6] = hex"";
+        vm.expectRevert("Enter max slippage");
+        f.act(hex"", action);
+
+        action = _swap(1 ether, TOKEN_ETH, uint160(dai), 10000);
+        vm.expectRevert("Slippage must be under 100%");
+        f.act(hex"", action);
+    }
+
+    function _mockAllowance(uint256 allowance) internal {
+        vm.mockCall(
+            dai,
+            abi.encodeCall(
+                IERC20.allowance,
+                (address(this), address(uniRouter))
+            ),
+            abi.encode(allowance)
+        );
+    }
+
+    function _swap(
+        uint256 amountIn,
+        uint256 tokenIn,
+        uint256 tokenOut,
+        uint256 slippageBps
+    ) internal pure returns (Action memory action) {
+        action.buttonKey = 5;
+        action.inputs = new bytes[](7);
+        action.inputs[2] = abi.encode(amountIn);
+        action.inputs[3] = abi.encode(tokenIn);
+        action.inputs[4] = abi.encode(tokenOut);
+        action.inputs[6] = abi.encode(slippageBps);
+    }
 }
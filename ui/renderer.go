@@ -8,10 +8,13 @@ import (
 	"math/big"
 	"strconv"
 	"strings"
+	"time"
 
 	"dcposch.eth/cli/act"
 	"dcposch.eth/cli/eth"
 	"dcposch.eth/cli/util"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
@@ -27,16 +30,37 @@ const (
 var (
 	app              *tview.Application
 	urlInput         *tview.InputField
+	tabBar           *tview.TextView
 	chainStatus      *tview.TextView
+	accountList      *tview.List
+	leftPane         *tview.Flex
 	mainContent      *tview.Flex
 	footerConnStatus *tview.TextView
 	footerMain       *tview.TextView
 	pages            *tview.Pages
 	modalConfirm     *tview.Modal
+	unlockForm       *tview.Form
+	unlockPassword   *tview.InputField
+	feeForm          *tview.Form
+	feePreset        *tview.DropDown
+	feeMaxInput      *tview.InputField
+	feeTipInput      *tview.InputField
+	feeGasInput      *tview.InputField
+	importPage       *tview.Flex
+	importInput      *tview.InputField
+	importReview     *tview.TextView
+	txList           *tview.List
+	txDetail         *tview.TextView
 )
 
 var (
 	lastState    *act.State
+	lastTabIx    int
+	lastNumTabs  int
+	lastUrl      string
+	lastAccounts string
+	lastTxLog    string
+	lastButtons  string
 	lastVdom     []eth.VElem
 	lastStateStr string
 )
@@ -50,18 +74,34 @@ func StartRenderer() {
 	// Header row
 	appLabel := tview.NewTextView().SetTextColor(fgGreen).SetText("ETHEREUM EXPLORER")
 	urlInput = tview.NewInputField().SetLabel("ENS or address: ").SetDoneFunc(onDoneUrlInput)
+	tabBar = tview.NewTextView().
+		SetDynamicColors(true).
+		SetRegions(true).
+		SetWrap(false).
+		SetHighlightedFunc(onHighlightTab)
 	grid.AddItem(appLabel, 0, 0, 1, 1, 0, 0, false)
 	grid.AddItem(urlInput, 0, 1, 1, 1, 0, 0, true)
-	grid.AddItem(tview.NewTextView(), 0, 2, 1, 1, 0, 0, false)
+	grid.AddItem(tabBar, 0, 2, 1, 1, 0, 0, false)
 
 	// Main row
 	chainStatus = tview.NewTextView().SetText("ACCOUNT")
 	chainStatus.SetBorderPadding(1, 1, 0, 0)
+	accountList = tview.NewList().SetSelectedFunc(onSelectAccount)
+	leftPane = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(chainStatus, 3, 0, false).
+		AddItem(accountList, 0, 1, false)
 	mainContent = tview.NewFlex().SetDirection(tview.FlexColumnCSS)
 	mainContent.SetBorderPadding(1, 1, 1, 1)
-	grid.AddItem(chainStatus, 1, 0, 1, 1, 0, 0, false)
+	grid.AddItem(leftPane, 1, 0, 1, 1, 0, 0, false)
 	grid.AddItem(mainContent, 1, 1, 1, 1, 0, 0, false)
-	grid.AddItem(tview.NewTextView(), 1, 2, 1, 1, 0, 0, false)
+	txList = tview.NewList().SetChangedFunc(onHighlightTxRecord)
+	txDetail = tview.NewTextView().SetWrap(true)
+	rightPane := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(tview.NewTextView().SetText("HISTORY (Alt+H)"), 2, 0, false).
+		AddItem(txList, 0, 1, false).
+		AddItem(txDetail, 12, 0, false)
+	rightPane.SetBorderPadding(0, 0, 1, 1)
+	grid.AddItem(rightPane, 1, 2, 1, 1, 0, 0, false)
 
 	// Footer row
 	footerConnStatus = tview.NewTextView()
@@ -71,29 +111,150 @@ func StartRenderer() {
 	grid.AddItem(tview.NewTextView(), 2, 2, 1, 1, 0, 0, false)
 
 	modalConfirm = tview.NewModal().
-		AddButtons([]string{"Confirm", "Cancel"}).
 		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
-			if buttonIndex == 0 {
+			switch buttonLabel {
+			case "Confirm":
 				txModalConfirm()
-			} else {
+			case "Export":
+				txModalExport()
+			case "Fees":
+				act.Dispatch(&act.ActShowFees{Show: true})
+			case "Speed up":
+				act.Dispatch(&act.ActReplaceTx{})
+			case "Cancel tx":
+				act.Dispatch(&act.ActReplaceTx{Cancel: true})
+			default:
 				txModalCancel()
 			}
 		})
 
+	unlockPassword = tview.NewInputField().
+		SetLabel("Password ").
+		SetMaskCharacter('*').
+		SetFieldWidth(32)
+	unlockForm = tview.NewForm().
+		AddFormItem(unlockPassword).
+		AddButton("Unlock", onUnlock).
+		AddButton("Cancel", func() {
+			unlockPassword.SetText("")
+			act.Dispatch(&act.ActShowUnlock{Show: false})
+		})
+	unlockForm.SetBorder(true).SetTitle("Unlock account")
+	unlockPassword.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEnter {
+			onUnlock()
+		}
+	})
+
+	feePreset = tview.NewDropDown().SetLabel("Preset ")
+	feeMaxInput = tview.NewInputField().SetLabel("Max fee, gwei ").SetFieldWidth(20)
+	feeTipInput = tview.NewInputField().SetLabel("Tip, gwei ").SetFieldWidth(20)
+	feeGasInput = tview.NewInputField().SetLabel("Gas limit ").SetFieldWidth(20)
+	feeForm = tview.NewForm().
+		AddFormItem(feePreset).
+		AddFormItem(feeMaxInput).
+		AddFormItem(feeTipInput).
+		AddFormItem(feeGasInput).
+		AddButton("Apply", onApplyFee).
+		AddButton("Back", func() {
+			act.Dispatch(&act.ActShowFees{Show: false})
+		})
+	feeForm.SetBorder(true).SetTitle("Transaction fee")
+
+	importInput = tview.NewInputField().
+		SetLabel("Signed tx, hex or file ").
+		SetDoneFunc(func(key tcell.Key) {
+			if key == tcell.KeyEnter {
+				act.Dispatch(&act.ActImportRaw{Raw: importInput.GetText()})
+			}
+		})
+	importForm := tview.NewForm().
+		AddFormItem(importInput).
+		AddButton("Decode", func() {
+			act.Dispatch(&act.ActImportRaw{Raw: importInput.GetText()})
+		}).
+		AddButton("Broadcast", func() {
+			act.Dispatch(&act.ActBroadcastRaw{})
+		}).
+		AddButton("Close", func() {
+			act.Dispatch(&act.ActShowImport{Show: false})
+		})
+	importReview = tview.NewTextView()
+	importReview.SetBorderPadding(0, 0, 1, 1)
+	importPage = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(importForm, 5, 0, true).
+		AddItem(importReview, 0, 1, false)
+	importPage.SetBorder(true).SetTitle("Broadcast signed transaction")
+
 	pages = tview.NewPages().
 		AddPage("main", grid, true, true).
-		AddPage("modal", modalConfirm, true, false)
+		AddPage("modal", modalConfirm, true, false).
+		AddPage("fees", centered(feeForm, 60, 11), true, false).
+		AddPage("unlock", centered(unlockForm, 60, 9), true, false).
+		AddPage("import", centered(importPage, 100, 18), true, false)
 
 	app = tview.NewApplication().
 		SetRoot(pages, true).
 		EnableMouse(true)
 
-	// Tab order
+	// Tab order, plus browser tab and history shortcuts
 	app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		if event.Key() == tcell.KeyTab {
+		switch event.Key() {
+		case tcell.KeyTab:
 			moveFocus(1)
-		} else if event.Key() == tcell.KeyBacktab {
+		case tcell.KeyBacktab:
 			moveFocus(-1)
+		case tcell.KeyCtrlT:
+			act.Dispatch(&act.ActNewTab{})
+			return nil
+		case tcell.KeyCtrlW:
+			act.Dispatch(&act.ActCloseTab{Ix: lastTabIx})
+			return nil
+		case tcell.KeyCtrlU:
+			act.Dispatch(&act.ActShowUnlock{Show: true})
+			return nil
+		case tcell.KeyCtrlL:
+			act.Dispatch(&act.ActLock{})
+			return nil
+		case tcell.KeyF5:
+			act.Dispatch(&act.ActReload{})
+			return nil
+		case tcell.KeyCtrlR:
+			act.Dispatch(&act.ActShowImport{Show: true})
+			return nil
+		case tcell.KeyLeft, tcell.KeyRight:
+			// Alt+Left, Alt+Right navigate back and forward
+			if event.Modifiers()&tcell.ModAlt == 0 {
+				break
+			}
+			if event.Key() == tcell.KeyLeft {
+				act.Dispatch(&act.ActGoBack{})
+			} else {
+				act.Dispatch(&act.ActGoForward{})
+			}
+			return nil
+		case tcell.KeyRune:
+			// Alt+A picks an account
+			if event.Modifiers()&tcell.ModAlt != 0 && event.Rune() == 'a' {
+				app.SetFocus(accountList)
+				return nil
+			}
+			// Alt+R cycles auto-refresh: off, every block, 15s, 60s
+			if event.Modifiers()&tcell.ModAlt != 0 && event.Rune() == 'r' {
+				cycleAutoRefresh(lastState.ActiveTab())
+				return nil
+			}
+			// Alt+H browses transaction history
+			if event.Modifiers()&tcell.ModAlt != 0 && event.Rune() == 'h' {
+				app.SetFocus(txList)
+				return nil
+			}
+			// Alt+1 through Alt+9 switch tabs
+			r := event.Rune()
+			if event.Modifiers()&tcell.ModAlt != 0 && r >= '1' && r <= '9' {
+				act.Dispatch(&act.ActSwitchTab{Ix: int(r - '1')})
+				return nil
+			}
 		}
 		return event
 	})
@@ -105,33 +266,113 @@ func onDoneUrlInput(key tcell.Key) {
 	if key == tcell.KeyEnter {
 		act.Dispatch(&act.ActSetUrl{Url: urlInput.GetText()})
 	} else {
-		urlInput.SetText(lastState.Tab.EnteredAddr)
+		urlInput.SetText(lastState.ActiveTab().EnteredAddr)
+	}
+}
+
+func onSelectAccount(ix int, mainText, secondaryText string, shortcut rune) {
+	if isRendering {
+		return
+	}
+	act.Dispatch(&act.ActSwitchAccount{Ix: ix})
+	app.SetFocus(urlInput)
+}
+
+func onHighlightTxRecord(ix int, mainText, secondaryText string, shortcut rune) {
+	if isRendering {
+		return
+	}
+	renderTxDetail(lastState.TxLog, ix)
+}
+
+var autoRefreshModes = []act.ActSetAutoRefresh{
+	{Auto: false},
+	{Auto: true},
+	{Auto: true, Every: 15 * time.Second},
+	{Auto: true, Every: 60 * time.Second},
+}
+
+func cycleAutoRefresh(tab *act.TabState) {
+	next := 1
+	for i, m := range autoRefreshModes {
+		if m.Auto == tab.AutoRefresh && (!m.Auto || m.Every == tab.RefreshEvery) {
+			next = (i + 1) % len(autoRefreshModes)
+		}
+	}
+	mode := autoRefreshModes[next]
+	act.Dispatch(&mode)
+}
+
+func onUnlock() {
+	password := unlockPassword.GetText()
+	unlockPassword.SetText("")
+	act.Dispatch(&act.ActUnlock{Password: password})
+}
+
+// Wraps a primitive to show it centered at a fixed size, eg for a dialog.
+func centered(p tview.Primitive, width, height int) tview.Primitive {
+	return tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(p, height, 1, true).
+			AddItem(nil, 0, 1, false), width, 1, true).
+		AddItem(nil, 0, 1, false)
+}
+
+func onHighlightTab(added, removed, remaining []string) {
+	if isRendering || len(added) == 0 {
+		return
+	}
+	ix, err := strconv.Atoi(strings.TrimPrefix(added[0], "tab"))
+	if err == nil {
+		act.Dispatch(&act.ActSwitchTab{Ix: ix})
 	}
 }
 
 var isRendering = false
 
+// Shows a state snapshot. Called on the act goroutine; the snapshot is never
+// modified afterward, so the draw closure may read it on the UI goroutine.
 func Render(state *act.State) {
 	// TODO: better diff
 	stateStr := fmt.Sprintf("%#v", state)
 	if stateStr == lastStateStr {
 		return
 	}
+	lastStateStr = stateStr
 
 	app.QueueUpdateDraw(func() {
 		isRendering = true
-		log.Printf("ui Render %#v URL %s %s err '%s' elems %d", state.Chain,
-			state.Tab.EnteredAddr, state.Tab.ContractAddr, state.Tab.ErrorText,
-			len(state.Tab.Vdom))
+		tab := state.ActiveTab()
+		log.Printf("ui Render %#v tab %d URL %s %s err '%s' elems %d", state.Chain,
+			state.TabIx, tab.EnteredAddr, tab.ContractAddr, tab.ErrorText,
+			len(tab.Vdom))
+
+		switched := state.TabIx != lastTabIx || len(state.Tabs) != lastNumTabs
+		if switched {
+			// Switched tabs. Redraw from scratch.
+			lastVdom = nil
+		}
+		if switched || tab.EnteredAddr != lastUrl {
+			urlInput.SetText(tab.EnteredAddr)
+		}
 
 		renderChain(&state.Chain)
-		renderTab(&state.Tab)
+		renderTabBar(state)
+		renderTab(tab)
 		renderModal(state)
+		renderFees(tab)
+		renderUnlock(&state.Chain)
+		renderImport(&state.Import)
+		renderTxLog(state.TxLog)
 
 		lastState = state
-		lastVdom = make([]eth.VElem, len(state.Tab.Vdom))
-		copy(lastVdom, state.Tab.Vdom)
-		lastStateStr = stateStr
+		lastTabIx = state.TabIx
+		lastNumTabs = len(state.Tabs)
+		lastUrl = tab.EnteredAddr
+		lastVdom = make([]eth.VElem, len(tab.Vdom))
+		copy(lastVdom, tab.Vdom)
 		isRendering = false
 	})
 }
@@ -140,27 +381,64 @@ func txModalConfirm() {
 	act.Dispatch(&act.ActExecTx{})
 }
 
+func txModalExport() {
+	act.Dispatch(&act.ActExportTx{})
+}
+
 func txModalCancel() {
 	act.Dispatch(&act.ActCancelTx{})
 }
 
 func renderModal(state *act.State) {
-	propTx := state.Tab.ProposedTx
-	pendTx := state.Tab.PendingTx
+	tab := state.ActiveTab()
+	hasProposed := len(tab.ProposedTxs) > 0
+	pendTx := tab.PendingTx
+
+	step := ""
+	if tab.TxCount > 1 {
+		step = fmt.Sprintf(" %d of %d", tab.TxStep, tab.TxCount)
+	}
 
 	var show bool
-	if propTx == nil && pendTx == nil {
+	buttons := []string{"Cancel"}
+	if !hasProposed && pendTx == nil {
 		show = false
-	} else if state.Chain.PrivateKey == nil {
+	} else if pendTx != nil {
+		show = true
+		text := fmt.Sprintf("Transaction%s %s pending...", step, pendTx.Hash())
+		if n := len(tab.ReplacedTxs); n > 0 {
+			verb := "Replaced"
+			if eth.IsCancelTx(pendTx, tab.PendingFrom) {
+				verb = "Cancelling. Replaced"
+			}
+			text += fmt.Sprintf("\n\n%s %d time(s) at nonce %d. The first to land wins.", verb, n, pendTx.Nonce())
+		}
+		modalConfirm.SetText(text)
+		// Hide only stops tracking; the transaction stays in the mempool.
+		buttons = []string{"Hide"}
+		if state.CanReplace(tab) {
+			buttons = []string{"Speed up", "Cancel tx", "Hide"}
+		}
+	} else if state.Chain.Locked {
+		show = true
+		modalConfirm.SetText("Account locked. Press Ctrl+U to unlock.")
+	} else if state.Chain.IsWatchOnly() {
+		show = true
+		modalConfirm.SetText(txPreviewText(tab, step) + "\n\nWatch-only: export to sign offline.")
+		buttons = []string{"Export", "Fees", "Cancel"}
+	} else if state.Chain.Signer == nil {
 		show = true
 		modalConfirm.SetText("You must be logged in to submit transactions.")
 	} else {
 		show = true
-		if propTx != nil {
-			modalConfirm.SetText(fmt.Sprintf("Confirm transaction to %s?", propTx.To))
-		} else {
-			modalConfirm.SetText(fmt.Sprintf("Transaction %s pending...", pendTx.Hash()))
-		}
+		modalConfirm.SetText(txPreviewText(tab, step))
+		buttons = []string{"Confirm", "Fees", "Export", "Cancel"}
+	}
+
+	// Replacing buttons resets modal focus, so only do it when they change.
+	if btnStr := strings.Join(buttons, ","); btnStr != lastButtons {
+		modalConfirm.ClearButtons().AddButtons(buttons)
+		lastButtons = btnStr
 	}
 
 	frontPage, _ := pages.GetFrontPage()
@@ -171,40 +449,243 @@ func renderModal(state *act.State) {
 	}
 }
 
+var feeLabels = []string{"slow", "normal", "fast", "custom"}
+
+func renderFees(tab *act.TabState) {
+	frontPage, _ := pages.GetFrontPage()
+	if !tab.ShowFees || tab.FeeOptions == nil {
+		if frontPage == "fees" {
+			pages.HidePage("fees")
+		}
+		return
+	} else if frontPage == "fees" {
+		return
+	}
+
+	opts := tab.FeeOptions
+	presets := []eth.Fee{opts.Slow, opts.Normal, opts.Fast}
+	if opts.Normal.Legacy {
+		feeForm.SetTitle("Transaction fee. Pre-London chain, legacy gas price")
+		feeMaxInput.SetLabel("Gas price, gwei ")
+		feeTipInput.SetLabel("Tip, n/a ")
+	} else {
+		feeForm.SetTitle(fmt.Sprintf("Transaction fee. Base fee %s gwei", util.ToFixedPrecision(opts.BaseFee, 9)))
+		feeMaxInput.SetLabel("Max fee, gwei ")
+		feeTipInput.SetLabel("Tip, gwei ")
+	}
+	feePreset.SetOptions(feeLabels, func(text string, ix int) {
+		if ix < len(presets) {
+			feeMaxInput.SetText(util.ToFixedPrecision(presets[ix].MaxFee, 9))
+			feeTipInput.SetText(util.ToFixedPrecision(presets[ix].Tip, 9))
+		}
+	})
+	feeMaxInput.SetText(util.ToFixedPrecision(tab.PreparedTx.GasFeeCap(), 9))
+	feeTipInput.SetText(util.ToFixedPrecision(tab.PreparedTx.GasTipCap(), 9))
+	feeGasInput.SetText("")
+	if gas := tab.PreparedTx.Gas(); gas > 0 {
+		feeGasInput.SetText(strconv.FormatUint(gas, 10))
+	} else {
+		feeForm.SetTitle(fmt.Sprintf("Enter a gas limit. Step %d not estimated, step %d hasn't landed", tab.TxStep, tab.TxStep-1))
+	}
+	for i, label := range feeLabels {
+		if label == tab.FeeLabel {
+			feePreset.SetCurrentOption(i)
+		}
+	}
+
+	pages.ShowPage("fees")
+	app.SetFocus(feeForm)
+}
+
+func onApplyFee() {
+	maxFee, err := util.FromFixedPrecision(feeMaxInput.GetText(), 9)
+	if err != nil {
+		feeForm.SetTitle("Max fee: " + err.Error())
+		return
+	}
+	// Legacy transactions have a single gas price, no separate tip.
+	legacy := lastState.ActiveTab().FeeOptions.Normal.Legacy
+	tip := maxFee
+	if !legacy {
+		tip, err = util.FromFixedPrecision(feeTipInput.GetText(), 9)
+		if err != nil {
+			feeForm.SetTitle("Tip: " + err.Error())
+			return
+		}
+	}
+	if tip.Cmp(maxFee) > 0 {
+		feeForm.SetTitle("Tip cannot exceed max fee")
+		return
+	}
+	gas, err := strconv.ParseUint(strings.TrimSpace(feeGasInput.GetText()), 10, 64)
+	if err != nil || gas == 0 {
+		feeForm.SetTitle("Gas limit: enter a whole number")
+		return
+	}
+
+	// Matches a preset, unless the user edited the numbers.
+	label := "custom"
+	if ix, _ := feePreset.GetCurrentOption(); ix >= 0 && ix < 3 {
+		opts := lastState.ActiveTab().FeeOptions
+		preset := []eth.Fee{opts.Slow, opts.Normal, opts.Fast}[ix]
+		if preset.MaxFee.Cmp(maxFee) == 0 && preset.Tip.Cmp(tip) == 0 {
+			label = feeLabels[ix]
+		}
+	}
+	act.Dispatch(&act.ActSetFee{Label: label, Fee: eth.Fee{MaxFee: maxFee, Tip: tip, Legacy: legacy}, Gas: gas})
+}
+
+func renderUnlock(chain *act.ChainState) {
+	if chain.UnlockErrorText != "" {
+		unlockForm.SetTitle("Unlock account: " + chain.UnlockErrorText)
+	} else {
+		unlockForm.SetTitle("Unlock account " + chain.Account.Disp())
+	}
+
+	frontPage, _ := pages.GetFrontPage()
+	if chain.UnlockPrompt && frontPage != "unlock" {
+		pages.ShowPage("unlock")
+		app.SetFocus(unlockPassword)
+	} else if !chain.UnlockPrompt && frontPage == "unlock" {
+		pages.HidePage("unlock")
+	}
+}
+
+func renderImport(imp *act.ImportState) {
+	var lines []string
+	if imp.Tx != nil {
+		lines = eth.SignedTxSummary(imp.Tx, imp.From)
+	}
+	if imp.ErrorText != "" {
+		lines = append(lines, "", "Error: "+imp.ErrorText)
+	}
+	importReview.SetText(strings.Join(lines, "\n"))
+
+	frontPage, _ := pages.GetFrontPage()
+	if imp.Show && frontPage != "import" {
+		importInput.SetText("")
+		pages.ShowPage("import")
+		app.SetFocus(importInput)
+	} else if !imp.Show && frontPage == "import" {
+		pages.HidePage("import")
+	}
+}
+
+// Describes an unsigned transaction for the confirmation modal.
+func txPreviewText(tab *act.TabState, step string) string {
+	tx := tab.PreparedTx
+	if tx == nil {
+		return fmt.Sprintf("Preparing transaction%s...", step)
+	}
+	gwei := func(v *big.Int) string {
+		return util.ToFixedPrecision(v, 9) + " gwei"
+	}
+	ether := func(v *big.Int) string {
+		return util.ToFixedPrecision(v, 18) + " ETH"
+	}
+	lines := []string{
+		fmt.Sprintf("Confirm transaction%s: %s", step, eth.DecodeFunctionName(tx.Data())),
+		"",
+		fmt.Sprintf("To: %s", namedAddrText(tab.PreparedTo)),
+		fmt.Sprintf("Call: %s", eth.DecodeCalldata(tx.Data())),
+		fmt.Sprintf("Value: %s", ether(tx.Value())),
+	}
+	if tx.Gas() > 0 {
+		lines = append(lines, fmt.Sprintf("Gas: %d", tx.Gas()))
+	} else {
+		// See ActExportTx
+		lines = append(lines, fmt.Sprintf("Gas: not estimated, step %d hasn't landed. Set a limit under Fees.", tab.TxStep-1))
+	}
+	if tx.Type() == types.DynamicFeeTxType {
+		lines = append(lines,
+			fmt.Sprintf("Max fee: %s (%s)", gwei(tx.GasFeeCap()), tab.FeeLabel),
+			fmt.Sprintf("Tip: %s", gwei(tx.GasTipCap())))
+	} else {
+		lines = append(lines, fmt.Sprintf("Gas price: %s (%s)", gwei(tx.GasPrice()), tab.FeeLabel))
+	}
+	if al := tx.AccessList(); len(al) > 0 {
+		lines = append(lines, fmt.Sprintf("Access list: %d addresses, %d slots", len(al), al.StorageKeys()))
+	}
+	// Value plus gas limit times fee cap
+	lines = append(lines, fmt.Sprintf("Max cost: %s", ether(tx.Cost())))
+	return strings.Join(lines, "\n")
+}
+
+func renderTabBar(state *act.State) {
+	var sb strings.Builder
+	for i, tab := range state.Tabs {
+		title := "New tab"
+		if tab.EnteredAddr != "" {
+			title = tab.EnteredAddr
+		}
+		if tab.PendingTx != nil {
+			title = "⏳ " + title
+		} else if tab.AutoRefresh {
+			title = "↻ " + title
+		}
+		fmt.Fprintf(&sb, `["tab%d"] %d %s [""] `, i, i+1, tview.Escape(padRight(title, 16)))
+	}
+	tabBar.SetText(sb.String())
+	tabBar.Highlight(fmt.Sprintf("tab%d", state.TabIx))
+}
+
 func renderTab(tab *act.TabState) {
 	footerMain.SetBackgroundColor(bgGray)
 	if tab.EnteredAddr == "" {
 		footerMain.SetText("Enter a contract address to begin")
 	} else if tab.ContractAddr == nil && tab.ErrorText == "" {
 		footerMain.SetText("Resolving...")
+	} else if tab.AppErrorText != "" {
+		footerMain.SetText(fmt.Sprintf("App error: %s", tab.AppErrorText))
+		footerMain.SetBackgroundColor(bgErr)
+	} else if tab.NoticeText != "" {
+		footerMain.SetText(tab.NoticeText)
 	} else if tab.ContractAddr != nil {
-		footerMain.SetText(fmt.Sprintf("Resolved %s", tab.ContractAddr))
+		footerMain.SetText(fmt.Sprintf("Resolved %s", namedAddrText(tab.Contract)))
 	} else {
 		footerMain.SetText(fmt.Sprintf("Error: %s", tab.ErrorText))
 		footerMain.SetBackgroundColor(bgErr)
 	}
 
 	errText := tab.ErrorText
-	if errText == "" && tab.Vdom != nil {
+	if errText == "" && tab.Vdom == nil {
+		mainContent.Clear()
+	} else if errText == "" {
 		// TODO: update tview to support item replacement and insertion
 		// currently it only allows append + delete, which is not enough to
-		// implement vdom diffing.
-		hasClearedRest := false
-		for i, v := range tab.Vdom {
-			if len(lastVdom) > i && lastVdom[i].TypeHash == v.TypeHash && bytes.Equal(lastVdom[i].Data, v.Data) {
-				// match, skip
-				continue
-			} else if !hasClearedRest {
-				nElems := mainContent.GetItemCount()
-				log.Printf("Rendering tab elems. Matched %d, deleting %d, adding %d",
-					i, nElems-i, len(tab.Vdom)-i)
-				for mainContent.GetItemCount() > i {
-					// tview API is incomplete, making usage ugly
-					mainContent.RemoveItem(mainContent.GetItem(mainContent.GetItemCount() - 1))
-				}
-				hasClearedRest = true
+		// implement vdom diffing. Keep the unchanged prefix, rebuild the rest.
+		nMatch := 0
+		for nMatch < len(tab.Vdom) && nMatch < len(lastVdom) && nMatch < mainContent.GetItemCount() &&
+			lastVdom[nMatch].TypeHash == tab.Vdom[nMatch].TypeHash &&
+			bytes.Equal(lastVdom[nMatch].Data, tab.Vdom[nMatch].Data) {
+			nMatch++
+		}
+
+		// Rebuilding the focused item, eg on auto-refresh, must not lose
+		// focus or text the user hasn't committed yet.
+		focusIx := getFocusIx()
+		if len(lastVdom) == 0 {
+			// New page or tab switch, nothing to preserve
+			focusIx = -1
+		}
+		var focusText *string
+		if focusIx >= nMatch {
+			if in, ok := mainContent.GetItem(focusIx).(*tview.InputField); ok {
+				text := in.GetText()
+				focusText = &text
 			}
+		}
 
+		nElems := mainContent.GetItemCount()
+		if nMatch < len(tab.Vdom) || nElems > len(tab.Vdom)+1 {
+			log.Printf("Rendering tab elems. Matched %d, deleting %d, adding %d",
+				nMatch, nElems-nMatch, len(tab.Vdom)-nMatch)
+		}
+		for mainContent.GetItemCount() > nMatch {
+			// tview API is incomplete, making usage ugly
+			mainContent.RemoveItem(mainContent.GetItem(mainContent.GetItemCount() - 1))
+		}
+		for _, v := range tab.Vdom[nMatch:] {
 			// Add newly created item
 			key := v.DataElem.GetKey()
 			inputVal := tab.Inputs[key]
@@ -215,6 +696,14 @@ func renderTab(tab *act.TabState) {
 			}
 			mainContent.AddItem(item, 3, 0, false)
 		}
+
+		if errText == "" && focusIx >= nMatch && focusIx < len(tab.Vdom) {
+			item := mainContent.GetItem(focusIx)
+			if in, ok := item.(*tview.InputField); ok && focusText != nil {
+				in.SetText(*focusText)
+			}
+			app.SetFocus(item)
+		}
 	}
 	if errText != "" {
 		mainContent.Clear()
@@ -309,6 +798,28 @@ func createItem(elem eth.KeyElem, inputVal []byte) (tview.Primitive, error) {
 		ret.SetCurrentOption(selIx)
 		ret.SetFieldBackgroundColor(bgGray)
 		return ret, nil
+	case *eth.ElemTextbox:
+		label := padRight(e.Label, 24)
+		ret := tview.NewInputField().
+			SetLabel(label).
+			SetPlaceholder(e.Placeholder).
+			SetText(util.DecodeString(inputVal))
+		if e.MaxLength > 0 {
+			ret.SetAcceptanceFunc(tview.InputFieldMaxLength(int(e.MaxLength)))
+		}
+
+		ret.SetDoneFunc(func(key tcell.Key) {
+			if isRendering {
+				return
+			}
+			text := ret.GetText()
+			log.Printf("textbox Done: %d %s", e.Key, text)
+			setInput(e.Key, util.EncodeString(text))
+			if key == tcell.KeyEnter {
+				moveFocus(1)
+			}
+		})
+		return ret, nil
 	case *eth.ElemButton:
 		return tview.NewButton(e.Text).SetSelectedFunc(func() {
 			if isRendering {
@@ -316,6 +827,15 @@ func createItem(elem eth.KeyElem, inputVal []byte) (tview.Primitive, error) {
 			}
 			submit(e.Key)
 		}), nil
+	case *eth.ElemLink:
+		ret := tview.NewButton("→ " + e.Text).SetSelectedFunc(func() {
+			if isRendering {
+				return
+			}
+			navigate(e.Target)
+		})
+		ret.SetLabelColor(fgGreen).SetBackgroundColor(colReset)
+		return ret, nil
 	default:
 		return nil, fmt.Errorf("unimplemented: %t", elem)
 	}
@@ -331,6 +851,12 @@ func submit(buttonKey uint8) {
 	act.Dispatch(&act.ActSubmit{ButtonKey: buttonKey})
 }
 
+func navigate(url string) {
+	log.Printf("handling link to %s, resetting focus", url)
+	app.SetFocus(urlInput)
+	act.Dispatch(&act.ActSetUrl{Url: url})
+}
+
 func padRight(label string, width int) string {
 	if len(label) > width {
 		return label[:width-1] + "…"
@@ -339,16 +865,187 @@ func padRight(label string, width int) string {
 }
 
 func renderChain(chain *act.ChainState) {
-	if chain.PrivateKey == nil {
-		chainStatus.SetText("Not logged in")
+	var lines []string
+	if chain.Locked {
+		lines = append(lines, "🔒 "+chain.Account.Disp())
+	} else if chain.IsWatchOnly() {
+		lines = append(lines, "👁 "+chain.Account.Disp())
+	} else if chain.Signer == nil {
+		lines = append(lines, "Not logged in")
 	} else {
-		chainStatus.SetText("🔑 " + chain.Account.Disp())
+		lines = append(lines, "🔑 "+chain.Account.Disp())
+	}
+	if chain.Balance != nil {
+		lines = append(lines,
+			fmt.Sprintf("%s ETH", util.ToFixedPrecision(chain.Balance, 18)),
+			fmt.Sprintf("Nonce %d", chain.Nonce))
+	}
+	for _, tok := range chain.Tokens {
+		if tok.Info == nil || tok.Balance == nil {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s %s", util.ToFixedPrecision(tok.Balance, int(tok.Info.Decimals)), tok.Info.Symbol))
+	}
+	if chain.Conn.Verified {
+		lines = append(lines, fmt.Sprintf("Verified #%d %s", chain.Conn.VerifiedBlock, shortHash(chain.Conn.VerifiedHash.Hex())))
 	}
+	chainStatus.SetText(strings.Join(lines, "\n"))
+	// Border padding, plus a line each
+	leftPane.ResizeItem(chainStatus, len(lines)+2, 0)
+	renderAccounts(chain)
 
 	if chain.Conn.ErrorText == "" {
-		statusText := fmt.Sprintf("CONNECTED - %s", strings.ToUpper(chain.Conn.ChainName))
+		// Fits the 32-column footer, eg MAINNET #12345678 UNVERIFIED
+		statusText := strings.ToUpper(chain.Conn.ChainName)
+		if chain.Conn.Verified {
+			// The verified block, which may trail the RPC's head
+			statusText += fmt.Sprintf(" #%d VERIFIED", chain.Conn.VerifiedBlock)
+		} else {
+			if chain.Head > 0 {
+				statusText += fmt.Sprintf(" #%d", chain.Head)
+			}
+			statusText += " UNVERIFIED"
+		}
 		footerConnStatus.SetText(statusText).SetBackgroundColor(bgDark)
 	} else {
 		footerConnStatus.SetText("DISCONNECTED").SetBackgroundColor(bgErr)
 	}
 }
+
+// Account picker, shown when there are several accounts, eg from a mnemonic.
+func renderAccounts(chain *act.ChainState) {
+	var sb strings.Builder
+	for _, acc := range chain.Accounts {
+		fmt.Fprintf(&sb, "%s %s %s\n", acc.Addr.Addr, acc.Addr.Disp(), acc.Balance)
+	}
+	fmt.Fprintf(&sb, "%d", chain.AccountIx)
+	if sb.String() == lastAccounts {
+		// Unchanged. Don't reset the highlighted item.
+		return
+	}
+	lastAccounts = sb.String()
+
+	accountList.Clear()
+	for i, acc := range chain.Accounts {
+		main := fmt.Sprintf("%d. %s", i+1, acc.Addr.Disp())
+		if i == chain.AccountIx {
+			main += " ✓"
+		}
+		bal := "…"
+		if acc.Balance != nil {
+			bal = util.ToFixedPrecision(acc.Balance, 18) + " ETH"
+		}
+		accountList.AddItem(main, "   "+bal, 0, nil)
+	}
+	if len(chain.Accounts) > 0 {
+		accountList.SetCurrentItem(chain.AccountIx)
+	}
+}
+
+// Sent transaction history, newest first.
+func renderTxLog(txLog []act.TxRecord) {
+	var sb strings.Builder
+	for _, rec := range txLog {
+		fmt.Fprintf(&sb, "%s %s\n", rec.Hash, rec.Status)
+	}
+	if sb.String() == lastTxLog {
+		// Unchanged. Don't reset the highlighted item.
+		return
+	}
+	lastTxLog = sb.String()
+
+	ix := txList.GetCurrentItem()
+	txList.Clear()
+	for i := len(txLog) - 1; i >= 0; i-- {
+		rec := &txLog[i]
+		main := fmt.Sprintf("%s %s", txStatusIcon(rec.Status), rec.Call)
+		when := rec.SentAt.Local().Format("Jan 2 15:04")
+		txList.AddItem(main, fmt.Sprintf("  %s %s", shortHash(rec.Hash.Hex()), when), 0, nil)
+	}
+	if ix < txList.GetItemCount() {
+		txList.SetCurrentItem(ix)
+	}
+	renderTxDetail(txLog, txList.GetCurrentItem())
+}
+
+// Shows the ix-th history item. The list is newest first.
+func renderTxDetail(txLog []act.TxRecord, ix int) {
+	if len(txLog) == 0 {
+		txDetail.SetText("No transactions yet")
+	} else if ix >= 0 && ix < len(txLog) {
+		txDetail.SetText(txRecordText(&txLog[len(txLog)-1-ix]))
+	}
+}
+
+func txStatusIcon(status string) string {
+	switch status {
+	case act.TxPending:
+		return "⏳"
+	case act.TxSuccess:
+		return "✓"
+	case act.TxReverted:
+		return "✗"
+	case act.TxCancelled:
+		return "⊘"
+	default:
+		return "?"
+	}
+}
+
+// Details of a sent transaction, shown under the history list.
+func txRecordText(rec *act.TxRecord) string {
+	optAddr := func(a *common.Address) string {
+		if a == nil {
+			return "-"
+		}
+		return a.Hex()
+	}
+	toName := rec.ToName
+	if eth.IsZeroAddr(toName.Addr) && rec.To != nil {
+		// Logged before names were recorded
+		toName.Addr = *rec.To
+	}
+	lines := []string{
+		fmt.Sprintf("Hash: %s", rec.Hash),
+		fmt.Sprintf("Status: %s", rec.Status),
+		fmt.Sprintf("Chain: %d", rec.ChainID),
+		fmt.Sprintf("From: %s", rec.From),
+		fmt.Sprintf("To: %s", namedAddrText(toName)),
+		fmt.Sprintf("Frontend: %s", optAddr(rec.Frontend)),
+		fmt.Sprintf("Nonce: %d", rec.Nonce),
+	}
+	if rec.Status != act.TxPending {
+		price := "-"
+		if rec.EffectiveGasPrice != nil {
+			price = util.ToFixedPrecision(rec.EffectiveGasPrice, 9) + " gwei"
+		}
+		lines = append(lines,
+			fmt.Sprintf("Block: %d", rec.Block),
+			fmt.Sprintf("Gas used: %d", rec.GasUsed),
+			fmt.Sprintf("Gas price: %s", price))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Full address, plus ENS name if any, eg "vitalik.eth (0xd8dA…)". Flags a
+// reverse name that doesn't resolve back to the address.
+func namedAddrText(a eth.NamedAddr) string {
+	if eth.IsZeroAddr(a.Addr) && a.Name == "" {
+		return "-"
+	} else if a.Name == "" {
+		return a.Addr.Hex()
+	}
+	ret := fmt.Sprintf("%s (%s)", a.Disp(), a.Addr.Hex())
+	if a.Err != "" {
+		ret += ". " + a.Err
+	}
+	return ret
+}
+
+// Abbreviates a hash or address, eg 0x1234…abcd
+func shortHash(s string) string {
+	if len(s) <= 12 {
+		return s
+	}
+	return s[:6] + "…" + s[len(s)-4:]
+}
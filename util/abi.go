@@ -3,6 +3,7 @@ package util
 import (
 	"math/big"
 
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	gethmath "github.com/ethereum/go-ethereum/common/math"
 )
 
@@ -13,3 +14,28 @@ func EncodeUint(v *big.Int) []byte {
 func DecodeUint(bytes []byte) *big.Int {
 	return big.NewInt(0).SetBytes(bytes)
 }
+
+var abiString = abi.Arguments{{Type: mustNewType("string")}}
+
+func mustNewType(t string) abi.Type {
+	typ, err := abi.NewType(t, "", nil)
+	Must(err)
+	return typ
+}
+
+func EncodeString(v string) []byte {
+	ret, err := abiString.Pack(v)
+	Must(err)
+	return ret
+}
+
+func DecodeString(bytes []byte) string {
+	if len(bytes) == 0 {
+		return ""
+	}
+	vals, err := abiString.Unpack(bytes)
+	if err != nil {
+		return ""
+	}
+	return vals[0].(string)
+}
@@ -1,6 +1,7 @@
 package util
 
 import (
+	"fmt"
 	"math/big"
 	"strings"
 )
@@ -14,3 +15,20 @@ func ToFixedPrecision(val *big.Int, dec int) string {
 	}
 	return ret
 }
+
+// Parses a decimal string like "1.5" into a fixed-point integer, eg 1500 for dec=3.
+func FromFixedPrecision(str string, dec int) (*big.Int, error) {
+	str = strings.TrimSpace(str)
+	if str == "" {
+		return nil, fmt.Errorf("missing amount")
+	}
+	whole, frac, _ := strings.Cut(str, ".")
+	if len(frac) > dec {
+		return nil, fmt.Errorf("%s has more than %d decimals", str, dec)
+	}
+	ret, ok := new(big.Int).SetString(whole+frac+strings.Repeat("0", dec-len(frac)), 10)
+	if !ok || ret.Sign() < 0 {
+		return nil, fmt.Errorf("invalid amount %s", str)
+	}
+	return ret, nil
+}